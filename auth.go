@@ -0,0 +1,96 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"errors"
+	"fmt"
+)
+
+const defaultAuthCommand = "auth"
+
+// Auth describes the login exchange performed right after every
+// connection is established and before any other command is sent.
+// Secret is sent as the only argument of Command, e.g. `auth "secret"`.
+type Auth struct {
+	// Command name, "auth" if empty.
+	Command string
+	Secret  string
+}
+
+// AuthError is returned by Connect when the server rejects credentials.
+type AuthError struct {
+	msg string
+}
+
+func (e AuthError) Error() string {
+	return "authentication failed: " + e.msg
+}
+
+func IsAuthError(err error) bool {
+	var e AuthError
+
+	return errors.As(err, &e)
+}
+
+// login performs the authentication exchange. It must be called before
+// the read() goroutine is started. The exchange is limited by the read
+// and write timeouts of the connection, like any other command.
+func (s *session) login(a *Auth) error {
+	name := a.Command
+	if name == "" {
+		name = defaultAuthCommand
+	}
+
+	if err := s.conn.Await(true); err != nil {
+		return err
+	}
+	err := s.exchange(newCall(name, a.Secret))
+	if aerr := s.conn.Await(false); err == nil {
+		err = aerr
+	}
+
+	var serr ServerError
+	if errors.As(err, &serr) {
+		return AuthError{serr.Error()}
+	}
+	if err != nil {
+		return fmt.Errorf("authentication: %w", err)
+	}
+
+	return nil
+}
+
+func (s *session) exchange(c *call) error {
+	if _, err := s.conn.WriteLine(c.line); err != nil {
+		return err
+	}
+	if err := s.conn.Flush(); err != nil {
+		return err
+	}
+	event, _, err := s.readResp()
+	if err != nil {
+		return err
+	}
+	if event != "" {
+		return newProtocolError(c.name, "EVENT "+event, false,
+			errors.New("unexpected event"))
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"errors"
+
+	"github.com/vchimishuk/chubby/time"
+)
+
+var ErrBatchExecuted = errors.New("batch already executed")
+
+// Batch collects several commands which are sent to the server at once
+// and answered in a single round trip. Every command method returns
+// a Future which is resolved by Exec.
+type Batch struct {
+	c        *Chubby
+	calls    []*call
+	resolves []func()
+	executed bool
+}
+
+func (c *Chubby) Batch() *Batch {
+	return &Batch{c: c}
+}
+
+func (b *Batch) CreatePlaylist(name string) *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdCreatePlaylist, name)
+}
+
+func (b *Batch) DeletePlaylist(name string) *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdDeletePlaylist, name)
+}
+
+func (b *Batch) List(path string) *Future[[]Entry] {
+	return batchAdd(b, decodeEntries, cmdList, path)
+}
+
+func (b *Batch) Next() *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdNext)
+}
+
+func (b *Batch) Pause() *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdPause)
+}
+
+func (b *Batch) Ping() *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdPing)
+}
+
+func (b *Batch) Play(pth string) *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdPlay, pth)
+}
+
+func (b *Batch) Playlists() *Future[[]*Playlist] {
+	return batchAdd(b, decodePlaylists, cmdPlaylists)
+}
+
+func (b *Batch) Prev() *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdPrev)
+}
+
+func (b *Batch) RenamePlaylist(from, to string) *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdRenamePlaylist, from, to)
+}
+
+func (b *Batch) Seek(time time.Time, mode SeekMode) *Future[struct{}] {
+	t, rel := seekArgs(time, mode)
+
+	return batchAdd(b, decodeNothing, cmdSeek, t, rel)
+}
+
+// SeekMillis is like Seek but with millisecond precision.
+func (b *Batch) SeekMillis(t time.Millis, mode SeekMode) *Future[struct{}] {
+	arg, rel := seekMillisArgs(t, mode)
+
+	return batchAdd(b, decodeNothing, cmdSeek, arg, rel)
+}
+
+func (b *Batch) Status() *Future[*Status] {
+	return batchAdd(b, decodeStatus, cmdStatus)
+}
+
+func (b *Batch) Stop() *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdStop)
+}
+
+func (b *Batch) Volume(vol int, mode VolumeMode) *Future[struct{}] {
+	return batchAdd(b, decodeNothing, cmdVolume, vol, mode)
+}
+
+// Exec sends all collected commands with a single flush and waits for
+// all responses. The returned error is not nil only if the commands
+// could not be sent, per-command errors are reported by futures.
+// A batch can be executed only once.
+func (b *Batch) Exec() error {
+	if b.executed {
+		return ErrBatchExecuted
+	}
+	b.executed = true
+	if len(b.calls) == 0 {
+		return nil
+	}
+
+	var err error
+	if s := b.c.sess.Load(); s != nil {
+		err = s.send(b.calls...)
+	} else {
+		err = ErrNotConnected
+		for _, c := range b.calls {
+			c.complete(nil, err)
+		}
+	}
+	for _, r := range b.resolves {
+		r()
+	}
+
+	return err
+}
+
+func batchAdd[T any](b *Batch, decode func(*Response) (T, error),
+	name string, args ...interface{}) *Future[T] {
+
+	f := newFuture[T]()
+	c := newCall(name, args...)
+	b.calls = append(b.calls, c)
+	b.resolves = append(b.resolves, func() {
+		<-c.done
+		if c.err != nil {
+			var zero T
+			f.resolve(zero, c.err)
+		} else {
+			f.resolve(decode(c.response()))
+		}
+	})
+
+	return f
+}
+
+func decodeNothing(*Response) (struct{}, error) {
+	return struct{}{}, nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vchimishuk/chubby/textconn"
+)
+
+var batchResps = map[string][]string{
+	"status": {`state: "stopped", volume: 50`},
+	"playlists": {`name: "foo", duration: 10, length: 2`,
+		`name: "bar", duration: 20, length: 3`},
+	"list": {`type: "dir", path: "/music/a", name: "a"`,
+		`type: "track", path: "/music/b.flac", artist: "Artist", ` +
+			`album: "Album", year: 1977, title: "B", number: 2, length: 180`},
+}
+
+// latencyResponder answers commands using lookup and delays every
+// response by the given latency counting from the moment the command
+// was received. If inflight is not nil the maximum number of
+// simultaneously pending commands is stored to it.
+func latencyResponder(latency time.Duration, lookup lookupFunc,
+	inflight *int32) func(conn *textconn.TextConn) {
+
+	type resp struct {
+		due    time.Time
+		header string
+		body   []string
+	}
+
+	return func(conn *textconn.TextConn) {
+		var cur int32
+		ch := make(chan resp, 64)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for r := range ch {
+				time.Sleep(time.Until(r.due))
+				atomic.AddInt32(&cur, -1)
+				if writeResp(conn, r.header, r.body...) != nil {
+					return
+				}
+			}
+		}()
+		defer func() {
+			close(ch)
+			<-done
+		}()
+
+		for {
+			line, err := conn.ReadLine()
+			if err != nil {
+				return
+			}
+			n := atomic.AddInt32(&cur, 1)
+			for inflight != nil {
+				m := atomic.LoadInt32(inflight)
+				if n <= m || atomic.CompareAndSwapInt32(inflight, m, n) {
+					break
+				}
+			}
+			r := resp{due: time.Now().Add(latency)}
+			r.header, r.body = lookup(line)
+			ch <- r
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(batchResps))
+	c := srv.connect(t)
+
+	b := c.Batch()
+	st := b.Status()
+	pls := b.Playlists()
+	ping := b.Ping()
+	ents := b.List("/music")
+	if err := b.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := st.Get()
+	if err != nil || s.State != StateStopped || s.Volume != 50 {
+		t.Fatalf("unexpected status: %v, %v", s, err)
+	}
+	p, err := pls.Get()
+	if err != nil || len(p) != 2 || p[1].Name != "bar" {
+		t.Fatalf("unexpected playlists: %v, %v", p, err)
+	}
+	if !IsServerError(ping.Err()) {
+		t.Fatalf("unexpected ping error: %v", ping.Err())
+	}
+	e, err := ents.Get()
+	if err != nil || len(e) != 2 || !e[0].IsDir() || e[1].Track().Year != 1977 {
+		t.Fatalf("unexpected entries: %v, %v", e, err)
+	}
+
+	if b.Exec() != ErrBatchExecuted {
+		t.Fatal("batch executed twice")
+	}
+}
+
+func TestBatchSeek(t *testing.T) {
+	checkLeaks(t)
+	rec := &recorder{}
+	srv := newTestServer(t, rec.handle)
+	c := srv.connect(t)
+
+	b := c.Batch()
+	fs := []*Future[struct{}]{
+		b.Seek(10, SeekModeAbs),
+		b.SeekMillis(1500, SeekModeForward),
+		b.SeekMillis(2000, SeekModeBackward),
+	}
+	if err := b.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fs {
+		if err := f.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exp := []string{"seek 10 false", "seek 1.5 true", "seek -2 true"}
+	if !reflect.DeepEqual(exp, rec.recorded()) {
+		t.Fatalf("%v != %v", exp, rec.recorded())
+	}
+}
+
+func TestBatchNotConnected(t *testing.T) {
+	c := &Chubby{}
+	b := c.Batch()
+	st := b.Status()
+	if err := b.Exec(); err != ErrNotConnected {
+		t.Fatalf("%v != %v", err, ErrNotConnected)
+	}
+	if _, err := st.Get(); err != ErrNotConnected {
+		t.Fatalf("%v != %v", err, ErrNotConnected)
+	}
+}
+
+const benchLatency = 2 * time.Millisecond
+
+func BenchmarkSequential(b *testing.B) {
+	srv := newTestServer(b, latencyResponder(benchLatency, lookupResps(batchResps), nil))
+	c := srv.connect(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Status(); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := c.Playlists(); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := c.List("/music"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBatch(b *testing.B) {
+	srv := newTestServer(b, latencyResponder(benchLatency, lookupResps(batchResps), nil))
+	c := srv.connect(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bt := c.Batch()
+		st := bt.Status()
+		pls := bt.Playlists()
+		ents := bt.List("/music")
+		if err := bt.Exec(); err != nil {
+			b.Fatal(err)
+		}
+		if err := st.Err(); err != nil {
+			b.Fatal(err)
+		}
+		if err := pls.Err(); err != nil {
+			b.Fatal(err)
+		}
+		if err := ents.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
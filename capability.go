@@ -0,0 +1,174 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vchimishuk/chubby/parser"
+)
+
+var ErrUnsupported = errors.New("unsupported command")
+
+// defaultDiscoveryTimeout limits the probe on Connect
+// if Chubby.ReadTimeout is not set.
+const defaultDiscoveryTimeout = 5 * time.Second
+
+// defaultUnknownCommand is the error message the chub daemon replies
+// with to commands it does not know.
+const defaultUnknownCommand = "unknown command"
+
+// Capabilities describes what is known about the connected server.
+type Capabilities struct {
+	// Commands maps command names to true if the server accepted
+	// the command and to false if it does not support it. Commands
+	// which were never sent are absent. The protocol has no way to
+	// list supported commands and most commands change the player
+	// state, so only status is probed on Connect and support of
+	// other commands is learned lazily from their first reply.
+	Commands map[string]bool
+	// Fields contains names of all status fields reported by the server.
+	Fields map[string]bool
+}
+
+type capabilities struct {
+	mu       sync.Mutex
+	unknown  string
+	commands map[string]bool
+	fields   map[string]bool
+}
+
+// newCapabilities creates capabilities which treat the server error
+// with the unknown message as a reply to an unsupported command.
+// Empty message means defaultUnknownCommand.
+func newCapabilities(unknown string) *capabilities {
+	if unknown == "" {
+		unknown = defaultUnknownCommand
+	}
+
+	return &capabilities{
+		unknown:  unknown,
+		commands: make(map[string]bool),
+		fields:   make(map[string]bool),
+	}
+}
+
+func (c *capabilities) unsupported(cmd string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	supported, ok := c.commands[cmd]
+
+	return ok && !supported
+}
+
+// record updates capabilities with the result of the command and
+// returns the error to be reported to the caller. Commands which
+// failed without a reply from the server, e.g. because
+// the connection was lost, are not recorded.
+func (c *capabilities) record(cmd string, lines []string, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var serr ServerError
+	var perr *ProtocolError
+	if errors.As(err, &serr) && c.isUnknownCommand(serr.Error()) {
+		c.commands[cmd] = false
+		return fmt.Errorf("%w: %s: %w", ErrUnsupported, cmd, err)
+	}
+	if err != nil && !errors.As(err, &serr) && !errors.As(err, &perr) {
+		return err
+	}
+	c.commands[cmd] = true
+
+	if cmd == cmdStatus && err == nil && len(lines) == 1 {
+		if m, err := parser.Parse(lines[0]); err == nil {
+			for k := range m {
+				c.fields[k] = true
+			}
+		}
+	}
+
+	return err
+}
+
+func (c *capabilities) snapshot() *Capabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	caps := &Capabilities{
+		Commands: make(map[string]bool, len(c.commands)),
+		Fields:   make(map[string]bool, len(c.fields)),
+	}
+	for k, v := range c.commands {
+		caps.Commands[k] = v
+	}
+	for k := range c.fields {
+		caps.Fields[k] = true
+	}
+
+	return caps
+}
+
+// Capabilities returns what is known about the connected server so far.
+// Status fields are populated by probing the server on Connect,
+// commands are recorded as their replies arrive.
+func (c *Chubby) Capabilities() *Capabilities {
+	s := c.sess.Load()
+	if s == nil {
+		return newCapabilities("").snapshot()
+	}
+
+	return s.caps.snapshot()
+}
+
+// discover probes the server with the status command, which has no
+// side effects, to record the status fields. Only errors which
+// terminate the connection are returned.
+func (s *session) discover(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cl := newCall(cmdStatus)
+	if err := s.send(cl); err != nil {
+		return err
+	}
+	select {
+	case <-cl.done:
+	case <-ctx.Done():
+		return fmt.Errorf("discovery: %w", ctx.Err())
+	}
+
+	var serr ServerError
+	var perr *ProtocolError
+	if cl.err == nil || errors.As(cl.err, &serr) ||
+		(errors.As(cl.err, &perr) && perr.Usable) {
+
+		return nil
+	}
+
+	return cl.err
+}
+
+func (c *capabilities) isUnknownCommand(msg string) bool {
+	return msg == c.unknown
+}
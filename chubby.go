@@ -1,4 +1,4 @@
-// Copyright 2017-2024 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+// Copyright 2017-2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
 //
 // This file is part of chubby.
 //
@@ -18,12 +18,14 @@
 package chubby
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net"
 	"strings"
+	"sync"
 	"sync/atomic"
+	stdtime "time"
 
 	"github.com/vchimishuk/chubby/parser"
 	"github.com/vchimishuk/chubby/textconn"
@@ -65,7 +67,8 @@ const (
 )
 
 const (
-	eventsChSize = 10
+	eventsChSize           = 10
+	defaultKeepAliveMisses = 3
 )
 
 type Playlist struct {
@@ -79,16 +82,36 @@ type Status struct {
 	Volume      int
 	PlaylistPos int
 	TrackPos    time.Time
-	Playlist    *Playlist
-	Track       *Track
+	// TrackPosMillis is TrackPos with millisecond precision if
+	// the server reports it, otherwise it is TrackPos in milliseconds.
+	TrackPosMillis time.Millis
+	Playlist       *Playlist
+	Track          *Track
 }
 
+// Entry is a library directory or a track. Dir and Track panic if
+// called on an entry of the other kind, AsDir and AsTrack functions
+// never do.
 type Entry interface {
 	IsDir() bool
 	Dir() *Dir
 	Track() *Track
 }
 
+// AsDir returns the directory and true if e is a non-nil *Dir.
+func AsDir(e Entry) (*Dir, bool) {
+	d, ok := e.(*Dir)
+
+	return d, ok && d != nil
+}
+
+// AsTrack returns the track and true if e is a non-nil *Track.
+func AsTrack(e Entry) (*Track, bool) {
+	t, ok := e.(*Track)
+
+	return t, ok && t != nil
+}
+
 type Dir struct {
 	Path string
 	Name string
@@ -114,6 +137,9 @@ type Track struct {
 	Title  string
 	Number int
 	Length time.Time
+	// LengthMillis is Length with millisecond precision if
+	// the server reports it, otherwise it is Length in milliseconds.
+	LengthMillis time.Millis
 }
 
 func (t *Track) IsDir() bool {
@@ -128,22 +154,90 @@ func (t *Track) Track() *Track {
 	return t
 }
 
-var ErrNotConnected = errors.New("not connected")
+var (
+	ErrNotConnected = errors.New("not connected")
+	ErrClosed       = errors.New("connection closed")
+)
+
+var closedCh = make(chan struct{})
+
+func init() {
+	close(closedCh)
+}
 
 type Chubby struct {
-	connected atomic.Bool
-	conn      *textconn.TextConn
-	resps     chan []string
-	events    chan Event
-	err       chan error
+	// ReadTimeout limits the time to wait for every response line.
+	ReadTimeout stdtime.Duration
+	// WriteTimeout limits the time to send a command.
+	WriteTimeout stdtime.Duration
+	// IdleTimeout closes the connection if nothing was received from
+	// the server during the given period, including events.
+	IdleTimeout stdtime.Duration
+	// MaxLineLength limits the length of a single protocol line.
+	MaxLineLength int
+	// KeepAlive enables sending ping commands after the given period
+	// of silence from the server.
+	KeepAlive stdtime.Duration
+	// KeepAliveMisses is the number of consecutive unanswered pings
+	// after which the connection is closed with ErrHeartbeat.
+	// Defaults to 3.
+	KeepAliveMisses int
+	// Auth enables authentication on every Connect. The exchange is
+	// limited by ReadTimeout and WriteTimeout.
+	Auth *Auth
+	// UnknownCommand is the error message the server replies with
+	// to commands it does not support. Such commands fail with
+	// ErrUnsupported. Defaults to "unknown command", the reply
+	// of the chub daemon.
+	UnknownCommand string
+
+	// Serializes Connect and Close calls.
+	mu    sync.Mutex
+	sess  atomic.Pointer[session]
+	hmu   sync.Mutex
+	hooks []*hook
+}
+
+// hook is a function called in its own goroutine after every
+// successful Connect.
+type hook struct {
+	f func()
 }
 
 func (c *Chubby) Connected() bool {
-	return c.connected.Load()
+	s := c.sess.Load()
+
+	return s != nil && !s.closed()
+}
+
+// Done returns a channel which is closed when the current connection
+// terminates for any reason. Err returns the reason afterwards.
+func (c *Chubby) Done() <-chan struct{} {
+	s := c.sess.Load()
+	if s == nil {
+		return closedCh
+	}
+
+	return s.done
+}
+
+// Err returns nil while the client is connected, ErrClosed if the
+// connection was closed by Close, ErrNotConnected if Connect was
+// never called, or the error which terminated the connection otherwise.
+func (c *Chubby) Err() error {
+	s := c.sess.Load()
+	if s == nil {
+		return ErrNotConnected
+	}
+
+	return s.reason()
 }
 
 func (c *Chubby) Connect(host string, port int) error {
-	if c.connected.Load() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Connected() {
 		return errors.New("already connected")
 	}
 
@@ -155,339 +249,407 @@ func (c *Chubby) Connect(host string, port int) error {
 	if err != nil {
 		return err
 	}
-	c.conn = textconn.New(conn)
-	c.connected.Store(true)
+	tc := textconn.New(conn)
+	tc.SetReadTimeout(c.ReadTimeout)
+	tc.SetWriteTimeout(c.WriteTimeout)
+	tc.SetIdleTimeout(c.IdleTimeout)
+	tc.SetMaxLineLength(c.MaxLineLength)
+
+	misses := c.KeepAliveMisses
+	if misses <= 0 {
+		misses = defaultKeepAliveMisses
+	}
 
-	c.resps = make(chan []string, 1)
-	c.events = make(chan Event, eventsChSize)
-	c.err = make(chan error, 1)
+	s := newSession(tc, c.UnknownCommand)
+	if c.Auth != nil {
+		if err := s.login(c.Auth); err != nil {
+			tc.Close()
+			return err
+		}
+	}
+	c.sess.Store(s)
+	s.start(c.KeepAlive, misses)
+	timeout := c.ReadTimeout
+	if timeout <= 0 {
+		timeout = defaultDiscoveryTimeout
+	}
+	if err := s.discover(timeout); err != nil {
+		s.shutdown(err)
+		s.wg.Wait()
+		return err
+	}
 
-	go c.read()
+	c.hmu.Lock()
+	for _, h := range c.hooks {
+		go h.f()
+	}
+	c.hmu.Unlock()
 
 	return nil
 }
 
+// Close closes the connection and waits for all background goroutines
+// to exit. It is safe to call Close multiple times and concurrently
+// with other methods.
 func (c *Chubby) Close() error {
-	if !c.connected.Load() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.sess.Load()
+	if s == nil {
 		return ErrNotConnected
 	}
-	err := c.conn.Close()
-	c.connected.Store(false)
-
-	// Wait for read() goroutine to exit.
-	<-c.err
+	err := s.shutdown(ErrClosed)
+	s.wg.Wait()
 
 	return err
 }
 
+func (c *Chubby) addConnectHook(f func()) *hook {
+	c.hmu.Lock()
+	defer c.hmu.Unlock()
+
+	h := &hook{f}
+	c.hooks = append(c.hooks, h)
+
+	return h
+}
+
+func (c *Chubby) removeConnectHook(h *hook) {
+	c.hmu.Lock()
+	defer c.hmu.Unlock()
+
+	for i, hh := range c.hooks {
+		if hh == h {
+			c.hooks = append(c.hooks[:i], c.hooks[i+1:]...)
+			return
+		}
+	}
+}
+
 func (c *Chubby) CreatePlaylist(name string) error {
-	_, err := c.cmd(cmdCreatePlaylist, name)
+	_, err := c.Do(context.Background(), cmdCreatePlaylist, name)
 
 	return err
 }
 
 func (c *Chubby) DeletePlaylist(name string) error {
-	_, err := c.cmd(cmdDeletePlaylist, name)
+	_, err := c.Do(context.Background(), cmdDeletePlaylist, name)
 
 	return err
 }
 
 func (c *Chubby) Events(enable bool) (<-chan Event, error) {
-	_, err := c.cmd(cmdEvents, enable)
+	s := c.sess.Load()
+	if s == nil {
+		return nil, ErrNotConnected
+	}
+	_, err := c.Do(context.Background(), cmdEvents, enable)
 
-	return c.events, err
+	return s.events, err
 }
 
 func (c *Chubby) Kill() error {
-	_, err := c.cmd(cmdKill)
+	_, err := c.Do(context.Background(), cmdKill)
 
 	return err
 }
 
 func (c *Chubby) List(path string) ([]Entry, error) {
-	lines, err := c.cmd(cmdList, path)
+	resp, err := c.Do(context.Background(), cmdList, path)
 	if err != nil {
 		return nil, err
 	}
 
-	entries := make([]Entry, len(lines))
-	for i, line := range lines {
-		entries[i], err = parseEntry(line)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return entries, nil
+	return decodeEntries(resp)
 }
 
 func (c *Chubby) Next() error {
-	_, err := c.cmd(cmdNext)
+	_, err := c.Do(context.Background(), cmdNext)
 
 	return err
 }
 
 func (c *Chubby) Pause() error {
-	_, err := c.cmd(cmdPause)
+	_, err := c.Do(context.Background(), cmdPause)
 
 	return err
 }
 
 func (c *Chubby) Ping() error {
-	_, err := c.cmd(cmdPing)
+	_, err := c.Do(context.Background(), cmdPing)
 
 	return err
 }
 
 func (c *Chubby) Play(pth string) error {
-	_, err := c.cmd(cmdPlay, pth)
+	_, err := c.Do(context.Background(), cmdPlay, pth)
 
 	return err
 }
 
 func (c *Chubby) Playlists() ([]*Playlist, error) {
-	lines, err := c.cmd(cmdPlaylists)
+	resp, err := c.Do(context.Background(), cmdPlaylists)
 	if err != nil {
 		return nil, err
 	}
 
-	pls := make([]*Playlist, len(lines))
-	for i, line := range lines {
-		pls[i], err = parsePlaylist(line)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return pls, nil
+	return decodePlaylists(resp)
 }
 
 func (c *Chubby) Prev() error {
-	_, err := c.cmd(cmdPrev)
+	_, err := c.Do(context.Background(), cmdPrev)
 
 	return err
 }
 
 func (c *Chubby) RenamePlaylist(from, to string) error {
-	_, err := c.cmd(cmdRenamePlaylist, from, to)
+	_, err := c.Do(context.Background(), cmdRenamePlaylist, from, to)
 
 	return err
 }
 
 func (c *Chubby) Seek(time time.Time, mode SeekMode) error {
-	var t int
-	var rel bool
+	t, rel := seekArgs(time, mode)
+	_, err := c.Do(context.Background(), cmdSeek, t, rel)
 
-	switch mode {
-	case SeekModeAbs:
-		t = int(time)
-		rel = false
-	case SeekModeBackward:
-		t = -int(time)
-		rel = true
-	case SeekModeForward:
-		t = int(time)
-		rel = true
-	default:
-		panic("unsupported SeekMode")
-	}
+	return err
+}
 
-	_, err := c.cmd(cmdSeek, t, rel)
+// SeekMillis is like Seek but with millisecond precision. Whole
+// seconds are sent as integers so servers without fractional seek
+// support still understand them.
+func (c *Chubby) SeekMillis(t time.Millis, mode SeekMode) error {
+	arg, rel := seekMillisArgs(t, mode)
+	_, err := c.Do(context.Background(), cmdSeek, arg, rel)
 
 	return err
 }
 
-func (c *Chubby) Status() (*Status, error) {
-	lines, err := c.cmd(cmdStatus)
+// SeekDuration is like Seek but accepts time.Duration rounded
+// to the nearest second.
+func (c *Chubby) SeekDuration(d stdtime.Duration, mode SeekMode) error {
+	return c.Seek(time.FromDuration(d), mode)
+}
 
-	if len(lines) != 1 {
-		return nil, err
+// SeekPosition seeks to the position returned by time.ParsePosition.
+// Percentage is calculated of the current track length.
+func (c *Chubby) SeekPosition(p time.Position) error {
+	if p.Mode != time.ModePercent {
+		return c.Seek(p.Time, SeekMode(p.Mode))
 	}
-
-	m, err := parser.Parse(lines[0])
-	if err != nil {
-		return nil, err
+	if !(p.Percent >= 0 && p.Percent <= 100) {
+		return errors.New("percentage out of range")
 	}
 
-	st, err := parseState(m["state"].(string))
+	s, err := c.Status()
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	s := &Status{
-		State:  st,
-		Volume: m["volume"].(int),
+	if s.Track == nil {
+		return errors.New("nothing is playing")
 	}
 
-	if st != StateStopped {
-		s.PlaylistPos = m["playlist-position"].(int)
-		s.TrackPos = time.Time(m["track-position"].(int))
-		s.Playlist = &Playlist{
-			Name:     m["playlist-name"].(string),
-			Duration: time.Time(m["playlist-duration"].(int)),
-			Length:   m["playlist-length"].(int),
-		}
-		s.Track = &Track{
-			Path:   m["track-path"].(string),
-			Artist: m["track-artist"].(string),
-			Album:  m["track-album"].(string),
-			Year:   m["track-year"].(int),
-			Title:  m["track-title"].(string),
-			Number: m["track-number"].(int),
-			Length: time.Time(m["track-length"].(int)),
-		}
+	return c.Seek(s.Track.Length.OfPercent(p.Percent), SeekModeAbs)
+}
+
+func (c *Chubby) Status() (*Status, error) {
+	resp, err := c.Do(context.Background(), cmdStatus)
+	if err != nil {
+		return nil, err
 	}
 
-	return s, nil
+	return decodeStatus(resp)
 }
 
 func (c *Chubby) Stop() error {
-	_, err := c.cmd(cmdStop)
+	_, err := c.Do(context.Background(), cmdStop)
 
 	return err
 }
 
 func (c *Chubby) Volume(vol int, mode VolumeMode) error {
-	_, err := c.cmd(cmdVolume, vol, mode)
+	_, err := c.Do(context.Background(), cmdVolume, vol, mode)
 
 	return err
 }
 
-func (c *Chubby) cmd(name string, args ...interface{}) ([]string, error) {
-	buf := name
-	for _, arg := range args {
-		buf += fmt.Sprintf(" %#v", arg)
-	}
-
-	if !c.connected.Load() {
-		return nil, ErrNotConnected
-	}
-	_, err := c.conn.WriteLine(buf)
+func decodeEntries(resp *Response) ([]Entry, error) {
+	ms, err := resp.Maps()
 	if err != nil {
 		return nil, err
 	}
-	err = c.conn.Flush()
+
+	entries := make([]Entry, len(ms))
+	for i, m := range ms {
+		entries[i] = parseEntry(m, parseMillis(resp.Lines[i]))
+	}
+
+	return entries, nil
+}
+
+func decodePlaylists(resp *Response) ([]*Playlist, error) {
+	ms, err := resp.Maps()
 	if err != nil {
 		return nil, err
 	}
 
-	select {
-	case r := <-c.resps:
-		return r, nil
-	case err = <-c.err:
-		return nil, err
+	pls := make([]*Playlist, len(ms))
+	for i, m := range ms {
+		pls[i] = parsePlaylist(m)
 	}
+
+	return pls, nil
 }
 
-func (c *Chubby) read() {
-	var err error
+func seekArgs(time time.Time, mode SeekMode) (int, bool) {
+	var t int
+	var rel bool
 
-	for {
-		var event string
-		var resp []string
-		var nerr net.Error
-		event, resp, err = c.readResp()
-		if err != nil {
-			if errors.As(err, &nerr) || errors.Is(err, io.EOF) {
-				break
-			} else {
-				c.err <- err
-			}
-		} else if event != "" {
-			if len(c.events) < eventsChSize {
-				var e Event
-				e, err = parseEvent(event, resp)
-				// Ignore invalid/unknown events.
-				if err == nil {
-					c.events <- e
-				}
-			}
-		} else {
-			c.resps <- resp
-		}
+	switch mode {
+	case SeekModeAbs:
+		t = int(time)
+		rel = false
+	case SeekModeBackward:
+		t = -int(time)
+		rel = true
+	case SeekModeForward:
+		t = int(time)
+		rel = true
+	default:
+		panic("unsupported SeekMode")
 	}
 
-	c.conn.Close()
-	c.connected.Store(false)
+	return t, rel
+}
+
+// seekMillisArgs is like seekArgs but returns fractional seconds
+// if t is not a whole number of seconds.
+func seekMillisArgs(t time.Millis, mode SeekMode) (interface{}, bool) {
+	if mode == SeekModeBackward {
+		t = -t
+	}
+	_, rel := seekArgs(0, mode)
+	if t%1000 == 0 {
+		return int(t / 1000), rel
+	}
 
-	c.err <- err
-	close(c.events)
-	close(c.resps)
-	close(c.err)
+	return t.Seconds(), rel
 }
 
-func (c *Chubby) readResp() (string, []string, error) {
-	line, err := c.conn.ReadLine()
+func decodeStatus(resp *Response) (*Status, error) {
+	if len(resp.Lines) != 1 {
+		return nil, newProtocolError(resp.Command,
+			strings.Join(resp.Lines, "\n"), true,
+			errors.New("invalid response"))
+	}
+
+	m, err := resp.Map(0)
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	st, err := parseStatus(m, parseMillis(resp.Lines[0]))
+	if err != nil {
+		return nil, newProtocolError(resp.Command, resp.Lines[0], true, err)
 	}
 
-	event := ""
-	pts := strings.SplitN(line, " ", 2)
-	if pts[0] == "OK" {
-		// Do nothing.
-	} else if pts[0] == "EVENT" {
-		if len(pts) != 2 {
-			return "", nil,
-				fmt.Errorf("protocol: invalid header")
-		}
-		event = pts[1]
-	} else if pts[0] == "ERR" {
-		if len(pts) != 2 {
-			return "", nil,
-				fmt.Errorf("protocol: invalid header")
+	return st, nil
+}
+
+// parseStatus creates the status from the line parsed with parser.Parse
+// and, for fields with millisecond precision, parser.ParseMillis.
+func parseStatus(m, ms map[string]interface{}) (*Status, error) {
+	st := StateStopped
+	if s, ok := m["state"].(string); ok {
+		var err error
+		st, err = parseState(s)
+		if err != nil {
+			return nil, err
 		}
-		return "", nil, newServerError(pts[1])
-	} else {
-		return "", nil, fmt.Errorf("protocol: invalid header")
 	}
 
-	lines := make([]string, 0, 8)
-	for {
-		line, err := c.conn.ReadLine()
-		if err != nil {
-			return "", nil, err
+	s := &Status{
+		State:  st,
+		Volume: num(m, "volume"),
+	}
+
+	if st != StateStopped {
+		s.PlaylistPos = num(m, "playlist-position")
+		s.TrackPos = time.Time(num(m, "track-position"))
+		s.TrackPosMillis = millis(ms, "track-position")
+		s.Playlist = &Playlist{
+			Name:     str(m, "playlist-name"),
+			Duration: time.Time(num(m, "playlist-duration")),
+			Length:   num(m, "playlist-length"),
 		}
-		if len(line) == 0 {
-			break
+		s.Track = &Track{
+			Path:         str(m, "track-path"),
+			Artist:       str(m, "track-artist"),
+			Album:        str(m, "track-album"),
+			Year:         num(m, "track-year"),
+			Title:        str(m, "track-title"),
+			Number:       num(m, "track-number"),
+			Length:       time.Time(num(m, "track-length")),
+			LengthMillis: millis(ms, "track-length"),
 		}
-		lines = append(lines, line)
 	}
 
-	return event, lines, nil
+	return s, nil
 }
 
-func parseEntry(s string) (Entry, error) {
-	m, err := parser.Parse(s)
-	if err != nil {
-		return nil, err
-	}
-
-	if tp, ok := m["type"].(string); ok && tp == "dir" {
-		return &Dir{Path: m["path"].(string),
-				Name: m["name"].(string)},
-			nil
+// parseEntry is like parseStatus but for list entries.
+func parseEntry(m, ms map[string]interface{}) Entry {
+	if str(m, "type") == "dir" {
+		return &Dir{Path: str(m, "path"),
+			Name: str(m, "name")}
 	} else {
-		return &Track{Path: m["path"].(string),
-				Artist: m["artist"].(string),
-				Album:  m["album"].(string),
-				Year:   m["year"].(int),
-				Title:  m["title"].(string),
-				Number: m["number"].(int),
-				Length: time.Time(m["length"].(int))},
-			nil
+		return &Track{Path: str(m, "path"),
+			Artist:       str(m, "artist"),
+			Album:        str(m, "album"),
+			Year:         num(m, "year"),
+			Title:        str(m, "title"),
+			Number:       num(m, "number"),
+			Length:       time.Time(num(m, "length")),
+			LengthMillis: millis(ms, "length")}
 	}
 }
 
-func parsePlaylist(s string) (*Playlist, error) {
-	m, err := parser.Parse(s)
-	if err != nil {
-		return nil, err
+func parsePlaylist(m map[string]interface{}) *Playlist {
+	return &Playlist{
+		Name:     str(m, "name"),
+		Duration: time.Time(num(m, "duration")),
+		Length:   num(m, "length"),
 	}
+}
 
-	return &Playlist{
-		Name:     m["name"].(string),
-		Duration: time.Time(m["duration"].(int)),
-		Length:   m["length"].(int),
-	}, nil
+// str returns the string field or an empty string if the field is
+// absent or has a different type, so that responses of older or newer
+// servers with different sets of fields can be decoded.
+func str(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+
+	return s
+}
+
+// num is like str but for integer fields.
+func num(m map[string]interface{}, key string) int {
+	n, _ := m[key].(int)
+
+	return n
+}
+
+// millis is like num but for time fields in seconds, which can be
+// fractional, of the map returned by parseMillis.
+func millis(m map[string]interface{}, key string) time.Millis {
+	n, _ := m[key].(int64)
+
+	return time.Millis(n)
+}
+
+// parseMillis parses the line which was already parsed with
+// parser.Parse using parser.ParseMillis, so it never fails.
+func parseMillis(line string) map[string]interface{} {
+	m, _ := parser.ParseMillis(line)
+
+	return m
 }
@@ -0,0 +1,669 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vchimishuk/chubby/textconn"
+	chubbytime "github.com/vchimishuk/chubby/time"
+)
+
+func TestDropPoints(t *testing.T) {
+	drops := map[string]func(conn *textconn.TextConn){
+		"accept": func(conn *textconn.TextConn) {},
+		"command": func(conn *textconn.TextConn) {
+			conn.ReadLine()
+		},
+		"header": func(conn *textconn.TextConn) {
+			conn.ReadLine()
+			conn.WriteLine("OK")
+			conn.Flush()
+		},
+		"body": func(conn *textconn.TextConn) {
+			conn.ReadLine()
+			conn.WriteLine("OK")
+			conn.WriteLine(`name: "foo"`)
+			conn.Flush()
+		},
+		"response": func(conn *textconn.TextConn) {
+			conn.ReadLine()
+			writeResp(conn, "OK")
+		},
+		"error": func(conn *textconn.TextConn) {
+			conn.ReadLine()
+			writeResp(conn, "ERR failed")
+		},
+		"event": func(conn *textconn.TextConn) {
+			conn.WriteLine("EVENT status")
+			conn.Flush()
+		},
+		"invalid-header": func(conn *textconn.TextConn) {
+			conn.ReadLine()
+			conn.WriteLine("FOO")
+			conn.Flush()
+			conn.ReadLine()
+		},
+	}
+
+	for name, drop := range drops {
+		t.Run(name, func(t *testing.T) {
+			checkLeaks(t)
+			srv := newTestServer(t, drop)
+			c := srv.connect(t)
+
+			c.Ping()
+			waitDone(t, c)
+			if c.Connected() {
+				t.Fatal("connected after drop")
+			}
+			if c.Err() == nil {
+				t.Fatal("no error after drop")
+			}
+			if err := c.Ping(); err != ErrNotConnected {
+				t.Fatalf("%v != %v", err, ErrNotConnected)
+			}
+			c.Close()
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{"ping": nil}))
+	c := srv.connect(t)
+
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Close()
+		}()
+	}
+	wg.Wait()
+	waitDone(t, c)
+	if c.Err() != ErrClosed {
+		t.Fatalf("%v != %v", c.Err(), ErrClosed)
+	}
+}
+
+func TestCloseWhileWaiting(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, func(conn *textconn.TextConn) {
+		// Never answer.
+		for {
+			if _, err := conn.ReadLine(); err != nil {
+				return
+			}
+		}
+	})
+	c := srv.connect(t)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.Ping()
+		}(i)
+	}
+	c.Close()
+	wg.Wait()
+	for _, err := range errs {
+		if err != ErrClosed && err != ErrNotConnected {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestConcurrentCommands(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{
+		"ping":      nil,
+		"playlists": {`name: "foo", duration: 10, length: 2`},
+	}))
+	c := srv.connect(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				if err := c.Ping(); err != nil {
+					t.Error(err)
+				}
+			} else {
+				pls, err := c.Playlists()
+				if err != nil {
+					t.Error(err)
+				} else if len(pls) != 1 || pls[0].Name != "foo" {
+					t.Errorf("unexpected playlists: %v", pls)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestReconnect(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{"ping": nil}))
+	c := srv.connect(t)
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusInvalidResponse(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{"status": nil}))
+	c := srv.connect(t)
+
+	s, err := c.Status()
+	if s != nil || !IsProtocolError(err) {
+		t.Fatalf("unexpected result: %v, %v", s, err)
+	}
+	// Connection must still be usable.
+	_, err = c.Status()
+	if !IsProtocolError(err) {
+		t.Fatal(err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(nil))
+	c := srv.connect(t)
+
+	err := c.Ping()
+	var serr ServerError
+	if !errors.As(err, &serr) || serr.Error() != "unknown command" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.Connected() {
+		t.Fatal("not connected")
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, func(conn *textconn.TextConn) {
+		// Accept commands but never answer them.
+		for {
+			if _, err := conn.ReadLine(); err != nil {
+				return
+			}
+		}
+	})
+	c := &Chubby{ReadTimeout: 50 * time.Millisecond}
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// Idle connection must not time out without pending commands.
+	time.Sleep(100 * time.Millisecond)
+	if !c.Connected() {
+		t.Fatal("idle connection closed")
+	}
+	err := c.Ping()
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitDone(t, c)
+}
+
+func TestIdleTimeout(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{"ping": nil}))
+	c := &Chubby{IdleTimeout: 50 * time.Millisecond}
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	waitDone(t, c)
+	if !errors.Is(c.Err(), os.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", c.Err())
+	}
+}
+
+func TestMaxLineLength(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{
+		"playlists": {`name: "` + strings.Repeat("a", 100) + `"`},
+	}))
+	c := &Chubby{MaxLineLength: 64}
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err := c.Playlists()
+	if err != textconn.ErrLineTooLong {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitDone(t, c)
+}
+
+func TestHeartbeat(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{"ping": nil}))
+	c := &Chubby{KeepAlive: 20 * time.Millisecond}
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for c.RTT() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no heartbeat")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !c.Healthy() {
+		t.Fatal("not healthy")
+	}
+}
+
+func TestHeartbeatMissed(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, func(conn *textconn.TextConn) {
+		for {
+			if _, err := conn.ReadLine(); err != nil {
+				return
+			}
+		}
+	})
+	c := &Chubby{KeepAlive: 20 * time.Millisecond, KeepAliveMisses: 2}
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	waitDone(t, c)
+	if c.Err() != ErrHeartbeat {
+		t.Fatalf("%v != %v", c.Err(), ErrHeartbeat)
+	}
+	if c.Healthy() {
+		t.Fatal("healthy after heartbeat timeout")
+	}
+}
+
+func TestDo(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{
+		"shuffle": {`enabled: true`, `foo bar`},
+	}))
+	c := srv.connect(t)
+
+	resp, err := c.Do(context.Background(), "shuffle", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Lines) != 2 || resp.Lines[1] != "foo bar" {
+		t.Fatalf("unexpected lines: %v", resp.Lines)
+	}
+	_, err = resp.Maps()
+	var perr *ProtocolError
+	if !errors.As(err, &perr) || perr.Command != "shuffle" ||
+		perr.Line != "foo bar" || perr.Offset != 4 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := resp.Map(0)
+	if err == nil || m != nil {
+		t.Fatalf("unexpected result: %v, %v", m, err)
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, latencyResponder(50*time.Millisecond,
+		lookupResps(map[string][]string{
+			"ping":      nil,
+			"playlists": {`name: "foo", duration: 10, length: 2`},
+		}), nil))
+	c := srv.connect(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+	_, err := c.Do(ctx, "ping")
+	if err != context.DeadlineExceeded {
+		t.Fatalf("%v != %v", err, context.DeadlineExceeded)
+	}
+	// Late ping response must not be taken for the playlists one.
+	pls, err := c.Playlists()
+	if err != nil || len(pls) != 1 || pls[0].Name != "foo" {
+		t.Fatalf("unexpected playlists: %v, %v", pls, err)
+	}
+}
+
+func TestConnectDropDuringDiscovery(t *testing.T) {
+	checkLeaks(t)
+	srv := newRawTestServer(t, func(conn *textconn.TextConn) {
+		conn.ReadLine()
+	})
+
+	c := &Chubby{}
+	if err := c.Connect("127.0.0.1", srv.port()); err == nil {
+		t.Fatal("connected")
+	}
+	if c.Connected() {
+		t.Fatal("connected")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, responder(map[string][]string{
+		"ping": nil,
+		"status": {`state: "playing", volume: 20, playlist-name: "foo", ` +
+			`track-path: "/a.flac", track-title: "A"`},
+	}))
+	c := srv.connect(t)
+
+	if caps := c.Capabilities(); !caps.Commands["status"] || !caps.Fields["volume"] {
+		t.Fatalf("unexpected capabilities: %+v", caps)
+	}
+
+	for i := 0; i < 2; i++ {
+		err := c.Kill()
+		if !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if supported, ok := c.Capabilities().Commands["kill"]; !ok || supported {
+		t.Fatal("kill is not marked as unsupported")
+	}
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Absent fields must be tolerated.
+	s, err := c.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != StatePlaying || s.Volume != 20 ||
+		s.Playlist.Name != "foo" || s.Track.Title != "A" ||
+		s.Track.Year != 0 {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+	if !c.Capabilities().Fields["track-title"] {
+		t.Fatal("status fields are not recorded")
+	}
+
+	caps := newCapabilities("")
+	for msg, exp := range map[string]bool{
+		"unknown command":              true,
+		"Unknown command":              false,
+		"unknown command-line option":  false,
+		"playlist has unknown command": false,
+	} {
+		if caps.isUnknownCommand(msg) != exp {
+			t.Errorf("%q: %t expected", msg, exp)
+		}
+	}
+
+	// Commands failed without a reply are not recorded.
+	for _, err := range []error{io.EOF, net.ErrClosed, ErrClosed} {
+		if caps.record("play", nil, err) != err {
+			t.Fatalf("unexpected error for %v", err)
+		}
+		if _, ok := caps.snapshot().Commands["play"]; ok {
+			t.Fatalf("play is recorded after %v", err)
+		}
+	}
+	caps.record("play", nil, NewServerError("nothing to play"))
+	if !caps.snapshot().Commands["play"] {
+		t.Fatal("play is not marked as supported")
+	}
+}
+
+func TestCapabilitiesUnknownCommand(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, func(conn *textconn.TextConn) {
+		for {
+			if _, err := conn.ReadLine(); err != nil {
+				return
+			}
+			writeResp(conn, "ERR no such command")
+		}
+	})
+	c := &Chubby{UnknownCommand: "no such command"}
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Kill(); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func authServer(t *testing.T, secret string) *testServer {
+	return newRawTestServer(t, func(conn *textconn.TextConn) {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		if line != `password "`+secret+`"` {
+			writeResp(conn, "ERR invalid password")
+			return
+		}
+		writeResp(conn, "OK")
+		if _, err := conn.ReadLine(); err != nil {
+			return
+		}
+		writeResp(conn, "OK", probeStatus)
+		responder(map[string][]string{"ping": nil})(conn)
+	})
+}
+
+func TestAuth(t *testing.T) {
+	checkLeaks(t)
+	srv := authServer(t, "secret")
+
+	c := &Chubby{Auth: &Auth{Command: "password", Secret: "secret"}}
+	for i := 0; i < 2; i++ {
+		if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Ping(); err != nil {
+			t.Fatal(err)
+		}
+		c.Close()
+	}
+}
+
+func TestAuthRejected(t *testing.T) {
+	checkLeaks(t)
+	srv := authServer(t, "secret")
+
+	c := &Chubby{Auth: &Auth{Command: "password", Secret: "wrong"}}
+	err := c.Connect("127.0.0.1", srv.port())
+	if !IsAuthError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Connected() {
+		t.Fatal("connected")
+	}
+}
+
+func TestAuthTimeout(t *testing.T) {
+	checkLeaks(t)
+	srv := newRawTestServer(t, func(conn *textconn.TextConn) {
+		conn.ReadLine()
+		conn.ReadLine()
+	})
+
+	c := &Chubby{ReadTimeout: 50 * time.Millisecond,
+		Auth: &Auth{Secret: "secret"}}
+	err := c.Connect("127.0.0.1", srv.port())
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Connected() {
+		t.Fatal("connected")
+	}
+}
+
+func TestFractionalTime(t *testing.T) {
+	checkLeaks(t)
+	seeks := make(chan string, 4)
+	srv := newTestServer(t, func(conn *textconn.TextConn) {
+		for {
+			line, err := conn.ReadLine()
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "seek ") {
+				seeks <- line
+				writeResp(conn, "OK")
+			} else {
+				writeResp(conn, "OK", `state: "playing", volume: 100, `+
+					`track-position: 12.345, track-length: 200, `+
+					`playlist-duration: 300.5`)
+			}
+		}
+	})
+	c := srv.connect(t)
+
+	s, err := c.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TrackPos != 12 || s.TrackPosMillis != 12345 {
+		t.Fatalf("unexpected position: %d, %d", s.TrackPos, s.TrackPosMillis)
+	}
+	if s.Track.Length != 200 || s.Track.LengthMillis != 200000 {
+		t.Fatalf("unexpected length: %d, %d", s.Track.Length, s.Track.LengthMillis)
+	}
+	if s.Playlist.Duration != 300 {
+		t.Fatalf("unexpected duration: %d", s.Playlist.Duration)
+	}
+
+	for _, test := range []struct {
+		t    int64
+		mode SeekMode
+		line string
+	}{
+		{1500, SeekModeAbs, "seek 1.5 false"},
+		{2000, SeekModeBackward, "seek -2 true"},
+		{250, SeekModeBackward, "seek -0.25 true"},
+	} {
+		if err := c.SeekMillis(chubbytime.Millis(test.t), test.mode); err != nil {
+			t.Fatal(err)
+		}
+		if line := <-seeks; line != test.line {
+			t.Fatalf("%q != %q", line, test.line)
+		}
+	}
+	if err := c.Seek(3, SeekModeForward); err != nil {
+		t.Fatal(err)
+	}
+	if line := <-seeks; line != "seek 3 true" {
+		t.Fatalf("unexpected seek: %q", line)
+	}
+
+	p, err := chubbytime.ParsePosition("25%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SeekPosition(p); err != nil {
+		t.Fatal(err)
+	}
+	if line := <-seeks; line != "seek 50 false" {
+		t.Fatalf("unexpected seek: %q", line)
+	}
+	p.Percent = math.NaN()
+	if err := c.SeekPosition(p); err == nil {
+		t.Fatal("NaN percentage is accepted")
+	}
+	select {
+	case line := <-seeks:
+		t.Fatalf("unexpected seek: %q", line)
+	default:
+	}
+}
+
+func TestEventSerialize(t *testing.T) {
+	events := []Event{
+		&CreatePlaylistEvent{Name: `a "quoted" \ name`},
+		&DeletePlaylistEvent{Name: "foo"},
+		&StatusEvent{State: StateStopped, Volume: 40},
+		&StatusEvent{State: StatePlaying, Volume: 100, PlaylistPos: 2,
+			TrackPos: 12, TrackPosMillis: 12345,
+			Playlist: &Playlist{Name: "foo", Duration: 300, Length: 3},
+			Track: &Track{Path: "/a.flac", Artist: "Artist",
+				Album: "Album", Year: 1977, Title: "A", Number: 1,
+				Length: 200, LengthMillis: 200000}},
+	}
+	for _, e := range events {
+		s := e.Serialize()
+		p, err := parseEvent(e.Event(), []string{s})
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if p.Serialize() != s {
+			t.Fatalf("%q != %q", p.Serialize(), s)
+		}
+		switch e := e.(type) {
+		case *CreatePlaylistEvent:
+			e.s = s
+		case *DeletePlaylistEvent:
+			e.s = s
+		case *StatusEvent:
+			e.s = s
+		}
+		if !reflect.DeepEqual(e, p) {
+			t.Fatalf("%+v != %+v", e, p)
+		}
+	}
+}
@@ -0,0 +1,533 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubbytest
+
+import (
+	"path"
+	"sync"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+// VFSPlaylist is the name of the playlist created by Player.Play
+// when a library path is played.
+const VFSPlaylist = "*vfs*"
+
+const eventsChSize = 16
+
+// Player is an in-memory chubby.Client which simulates the daemon
+// playback semantics over a library populated with AddTrack.
+// Playback time does not pass by itself, use Advance to move it.
+type Player struct {
+	mu        sync.Mutex
+	dirs      map[string][]chubby.Entry
+	names     []string
+	playlists map[string][]*chubby.Track
+	playing   string
+	state     chubby.State
+	volume    int
+	pos       int
+	trackPos  time.Millis
+	events    chan chubby.Event
+	notify    bool
+	killed    bool
+}
+
+var _ chubby.Client = (*Player)(nil)
+
+func NewPlayer() *Player {
+	return &Player{
+		dirs:      map[string][]chubby.Entry{"/": nil},
+		playlists: make(map[string][]*chubby.Track),
+		state:     chubby.StateStopped,
+		volume:    100,
+		events:    make(chan chubby.Event, eventsChSize),
+	}
+}
+
+// AddTrack adds the track to the library creating all missing
+// parent directories.
+func (p *Player) AddTrack(t *chubby.Track) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	dir := path.Dir(t.Path)
+	p.mkdir(dir)
+	p.dirs[dir] = append(p.dirs[dir], t)
+}
+
+// AddToPlaylist appends the library track or all tracks under the
+// library directory to the named playlist created with CreatePlaylist.
+func (p *Player) AddToPlaylist(name, pth string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	if _, ok := p.playlists[name]; !ok || name == VFSPlaylist {
+		return chubby.NewServerError("playlist not found")
+	}
+	pth = path.Clean(pth)
+	if _, ok := p.dirs[pth]; ok {
+		p.playlists[name] = p.collect(pth, p.playlists[name])
+		return nil
+	}
+	for _, t := range p.collect(path.Dir(pth), nil) {
+		if t.Path == pth {
+			p.playlists[name] = append(p.playlists[name], t)
+			return nil
+		}
+	}
+
+	return chubby.NewServerError("file not found")
+}
+
+// Advance simulates playback for the given period of time.
+func (p *Player) Advance(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != chubby.StatePlaying {
+		return
+	}
+	p.seek(p.trackPos + t.Millis())
+}
+
+func (p *Player) CreatePlaylist(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	if _, ok := p.playlists[name]; ok || name == VFSPlaylist {
+		return chubby.NewServerError("playlist already exists")
+	}
+	p.names = append(p.names, name)
+	p.playlists[name] = nil
+	p.emit(&chubby.CreatePlaylistEvent{Name: name})
+
+	return nil
+}
+
+func (p *Player) DeletePlaylist(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	if _, ok := p.playlists[name]; !ok {
+		return chubby.NewServerError("playlist not found")
+	}
+	delete(p.playlists, name)
+	for i, n := range p.names {
+		if n == name {
+			p.names = append(p.names[:i], p.names[i+1:]...)
+			break
+		}
+	}
+	p.emit(&chubby.DeletePlaylistEvent{Name: name})
+	if p.playing == name {
+		p.playing = ""
+		p.stop()
+	}
+
+	return nil
+}
+
+func (p *Player) Events(enable bool) (<-chan chubby.Event, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return nil, chubby.ErrNotConnected
+	}
+	p.notify = enable
+
+	return p.events, nil
+}
+
+// Kill stops the player. All subsequent calls fail with
+// chubby.ErrNotConnected.
+func (p *Player) Kill() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	p.stop()
+	p.killed = true
+	close(p.events)
+
+	return nil
+}
+
+func (p *Player) List(pth string) ([]chubby.Entry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return nil, chubby.ErrNotConnected
+	}
+	entries, ok := p.dirs[path.Clean(pth)]
+	if !ok {
+		return nil, chubby.NewServerError("directory not found")
+	}
+	res := make([]chubby.Entry, len(entries))
+	copy(res, entries)
+
+	return res, nil
+}
+
+func (p *Player) Next() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	if p.state != chubby.StateStopped {
+		p.next()
+	}
+
+	return nil
+}
+
+func (p *Player) Pause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	switch p.state {
+	case chubby.StatePlaying:
+		p.state = chubby.StatePaused
+	case chubby.StatePaused:
+		p.state = chubby.StatePlaying
+	default:
+		return nil
+	}
+	p.emitStatus()
+
+	return nil
+}
+
+func (p *Player) Ping() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+
+	return nil
+}
+
+// Play starts playback of the named playlist, of all tracks under the
+// library directory or of the directory of the given track starting
+// from it. Library tracks are played using VFSPlaylist.
+func (p *Player) Play(pth string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+
+	name := pth
+	pos := 0
+	tracks, ok := p.playlists[pth]
+	if !ok {
+		name = VFSPlaylist
+		pth = path.Clean(pth)
+		if _, ok := p.dirs[pth]; ok {
+			tracks = p.collect(pth, nil)
+		} else {
+			tracks = p.collect(path.Dir(pth), nil)
+			pos = -1
+			for i, t := range tracks {
+				if t.Path == pth {
+					pos = i
+					break
+				}
+			}
+			if pos == -1 {
+				return chubby.NewServerError("file not found")
+			}
+		}
+		p.playlists[VFSPlaylist] = tracks
+	}
+	if len(tracks) == 0 {
+		return chubby.NewServerError("nothing to play")
+	}
+
+	p.playing = name
+	p.pos = pos
+	p.trackPos = 0
+	p.state = chubby.StatePlaying
+	p.emitStatus()
+
+	return nil
+}
+
+func (p *Player) Playlists() ([]*chubby.Playlist, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return nil, chubby.ErrNotConnected
+	}
+	var pls []*chubby.Playlist
+	if _, ok := p.playlists[VFSPlaylist]; ok {
+		pls = append(pls, p.playlist(VFSPlaylist))
+	}
+	for _, n := range p.names {
+		pls = append(pls, p.playlist(n))
+	}
+
+	return pls, nil
+}
+
+func (p *Player) Prev() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	if p.state == chubby.StateStopped {
+		return nil
+	}
+	if p.pos > 0 {
+		p.pos--
+	}
+	p.trackPos = 0
+	p.emitStatus()
+
+	return nil
+}
+
+func (p *Player) RenamePlaylist(from, to string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	tracks, ok := p.playlists[from]
+	if !ok || from == VFSPlaylist {
+		return chubby.NewServerError("playlist not found")
+	}
+	if _, ok := p.playlists[to]; ok || to == VFSPlaylist {
+		return chubby.NewServerError("playlist already exists")
+	}
+	delete(p.playlists, from)
+	p.playlists[to] = tracks
+	for i, n := range p.names {
+		if n == from {
+			p.names[i] = to
+		}
+	}
+	if p.playing == from {
+		p.playing = to
+	}
+
+	return nil
+}
+
+func (p *Player) Seek(t time.Time, mode chubby.SeekMode) error {
+	return p.SeekMillis(t.Millis(), mode)
+}
+
+// SeekMillis is like Seek but with millisecond precision.
+func (p *Player) SeekMillis(t time.Millis, mode chubby.SeekMode) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	if p.state == chubby.StateStopped {
+		return nil
+	}
+	switch mode {
+	case chubby.SeekModeAbs:
+		p.seek(t)
+	case chubby.SeekModeBackward:
+		p.seek(p.trackPos - t)
+	case chubby.SeekModeForward:
+		p.seek(p.trackPos + t)
+	default:
+		panic("unsupported SeekMode")
+	}
+
+	return nil
+}
+
+func (p *Player) Status() (*chubby.Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return nil, chubby.ErrNotConnected
+	}
+
+	return p.status(), nil
+}
+
+func (p *Player) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	p.stop()
+
+	return nil
+}
+
+func (p *Player) Volume(vol int, mode chubby.VolumeMode) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.killed {
+		return chubby.ErrNotConnected
+	}
+	if mode == chubby.VolumeModeRel {
+		vol += p.volume
+	}
+	if vol < 0 {
+		vol = 0
+	} else if vol > 100 {
+		vol = 100
+	}
+	p.volume = vol
+	p.emitStatus()
+
+	return nil
+}
+
+func (p *Player) mkdir(dir string) {
+	if _, ok := p.dirs[dir]; ok {
+		return
+	}
+	parent := path.Dir(dir)
+	p.mkdir(parent)
+	p.dirs[dir] = nil
+	p.dirs[parent] = append(p.dirs[parent],
+		&chubby.Dir{Path: dir, Name: path.Base(dir)})
+}
+
+func (p *Player) collect(dir string, tracks []*chubby.Track) []*chubby.Track {
+	for _, e := range p.dirs[dir] {
+		if e.IsDir() {
+			tracks = p.collect(e.Dir().Path, tracks)
+		} else {
+			tracks = append(tracks, e.Track())
+		}
+	}
+
+	return tracks
+}
+
+func (p *Player) playlist(name string) *chubby.Playlist {
+	tracks := p.playlists[name]
+	pl := &chubby.Playlist{Name: name, Length: len(tracks)}
+	for _, t := range tracks {
+		pl.Duration += t.Length
+	}
+
+	return pl
+}
+
+func (p *Player) seek(t time.Millis) {
+	if t < 0 {
+		t = 0
+	}
+	track := p.playlists[p.playing][p.pos]
+	length := track.LengthMillis
+	if length == 0 {
+		length = track.Length.Millis()
+	}
+	if t >= length {
+		p.next()
+	} else {
+		p.trackPos = t
+		p.emitStatus()
+	}
+}
+
+func (p *Player) next() {
+	if p.pos+1 < len(p.playlists[p.playing]) {
+		p.pos++
+		p.trackPos = 0
+		p.emitStatus()
+	} else {
+		p.stop()
+	}
+}
+
+func (p *Player) stop() {
+	p.state = chubby.StateStopped
+	p.pos = 0
+	p.trackPos = 0
+	p.emitStatus()
+}
+
+func (p *Player) status() *chubby.Status {
+	s := &chubby.Status{State: p.state, Volume: p.volume}
+	if p.state != chubby.StateStopped {
+		t := *p.playlists[p.playing][p.pos]
+		s.PlaylistPos = p.pos
+		s.TrackPos = p.trackPos.Time()
+		s.TrackPosMillis = p.trackPos
+		s.Playlist = p.playlist(p.playing)
+		s.Track = &t
+	}
+
+	return s
+}
+
+func (p *Player) emitStatus() {
+	s := p.status()
+	p.emit(&chubby.StatusEvent{
+		State:          s.State,
+		Volume:         s.Volume,
+		PlaylistPos:    s.PlaylistPos,
+		TrackPos:       s.TrackPos,
+		TrackPosMillis: s.TrackPosMillis,
+		Playlist:       s.Playlist,
+		Track:          s.Track,
+	})
+}
+
+func (p *Player) emit(e chubby.Event) {
+	if !p.notify || p.killed {
+		return
+	}
+	select {
+	case p.events <- e:
+	default:
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubbytest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+func newTestPlayer() *Player {
+	p := NewPlayer()
+	p.AddTrack(&chubby.Track{Path: "/a/1.flac", Title: "One", Length: 60})
+	p.AddTrack(&chubby.Track{Path: "/a/2.flac", Title: "Two", Length: 60})
+	p.AddTrack(&chubby.Track{Path: "/a/b/3.flac", Title: "Three", Length: 60})
+
+	return p
+}
+
+func TestPlayerList(t *testing.T) {
+	p := newTestPlayer()
+
+	es, err := p.List("/")
+	if err != nil || len(es) != 1 || es[0].Dir().Path != "/a" {
+		t.Fatalf("unexpected entries: %v, %v", es, err)
+	}
+	es, err = p.List("/a")
+	if err != nil || len(es) != 3 || !es[2].IsDir() {
+		t.Fatalf("unexpected entries: %v, %v", es, err)
+	}
+	if _, err := p.List("/c"); !chubby.IsServerError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPlayerPlayback(t *testing.T) {
+	p := newTestPlayer()
+	assertState := func(state chubby.State, pos int, title string) {
+		t.Helper()
+		s, err := p.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.State != state || s.PlaylistPos != pos ||
+			(s.Track != nil && s.Track.Title != title) {
+			t.Fatalf("unexpected status: %+v", s)
+		}
+	}
+
+	if err := p.Play("/a"); err != nil {
+		t.Fatal(err)
+	}
+	assertState(chubby.StatePlaying, 0, "One")
+	p.Next()
+	assertState(chubby.StatePlaying, 1, "Two")
+	p.Pause()
+	assertState(chubby.StatePaused, 1, "Two")
+	p.Pause()
+	p.Prev()
+	assertState(chubby.StatePlaying, 0, "One")
+	p.Seek(30, chubby.SeekModeForward)
+	p.Advance(40)
+	assertState(chubby.StatePlaying, 1, "Two")
+	p.Seek(60, chubby.SeekModeAbs)
+	assertState(chubby.StatePlaying, 2, "Three")
+	p.Next()
+	assertState(chubby.StateStopped, 0, "")
+
+	if err := p.Play("/a/2.flac"); err != nil {
+		t.Fatal(err)
+	}
+	assertState(chubby.StatePlaying, 1, "Two")
+	p.Stop()
+	assertState(chubby.StateStopped, 0, "")
+}
+
+func TestPlayerSeekMillis(t *testing.T) {
+	p := newTestPlayer()
+	p.Play("/a")
+
+	p.SeekMillis(1500, chubby.SeekModeAbs)
+	p.Advance(2)
+	p.SeekMillis(250, chubby.SeekModeBackward)
+	s, _ := p.Status()
+	if s.TrackPosMillis != 3250 || s.TrackPos != 3 {
+		t.Fatalf("unexpected position: %d, %d", s.TrackPos, s.TrackPosMillis)
+	}
+	p.Seek(56, chubby.SeekModeForward)
+	p.SeekMillis(749, chubby.SeekModeForward)
+	s, _ = p.Status()
+	if s.PlaylistPos != 0 || s.TrackPosMillis != 59999 || s.TrackPos != 59 {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+	p.SeekMillis(1, chubby.SeekModeForward)
+	s, _ = p.Status()
+	if s.PlaylistPos != 1 || s.TrackPosMillis != 0 {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+}
+
+func TestPlayerVolume(t *testing.T) {
+	p := newTestPlayer()
+
+	p.Volume(50, chubby.VolumeModeAbs)
+	p.Volume(-70, chubby.VolumeModeRel)
+	s, _ := p.Status()
+	if s.Volume != 0 {
+		t.Fatalf("%d != 0", s.Volume)
+	}
+	p.Volume(170, chubby.VolumeModeRel)
+	s, _ = p.Status()
+	if s.Volume != 100 {
+		t.Fatalf("%d != 100", s.Volume)
+	}
+}
+
+func TestPlayerEvents(t *testing.T) {
+	p := newTestPlayer()
+
+	events, err := p.Events(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.CreatePlaylist("foo")
+	p.Play("/a")
+	e := <-events
+	if e.Event() != "create-playlist" || e.Serialize() != `name: "foo"` {
+		t.Fatalf("unexpected event: %s: %s", e.Event(), e.Serialize())
+	}
+	e = <-events
+	if s, ok := e.(*chubby.StatusEvent); !ok || s.State != chubby.StatePlaying {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	exp := `state: "playing", volume: 100, playlist-position: 0, ` +
+		`track-position: 0, playlist-name: "*vfs*", playlist-duration: 180, ` +
+		`playlist-length: 3, track-path: "/a/1.flac", track-artist: "", ` +
+		`track-album: "", track-year: 0, track-title: "One", ` +
+		`track-number: 0, track-length: 60`
+	if e.Serialize() != exp {
+		t.Fatalf("%s != %s", e.Serialize(), exp)
+	}
+}
+
+func TestPlayerAddToPlaylist(t *testing.T) {
+	p := newTestPlayer()
+
+	if err := p.AddToPlaylist("foo", "/a"); !chubby.IsServerError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.CreatePlaylist("foo")
+	if err := p.AddToPlaylist("foo", "/a/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddToPlaylist("foo", "/a/1.flac"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddToPlaylist("foo", "/a/4.flac"); !chubby.IsServerError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := p.Play("foo"); err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Playlist.Name != "foo" || s.Playlist.Length != 2 ||
+		s.Track.Title != "Three" {
+
+		t.Fatalf("unexpected status: %+v", s)
+	}
+	p.Next()
+	if s, _ := p.Status(); s.Track.Title != "One" {
+		t.Fatalf("unexpected track: %s", s.Track.Title)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	p := newTestPlayer()
+	r := NewRecorder(p)
+	fail := errors.New("fail")
+
+	r.Play("/a")
+	r.Volume(10, chubby.VolumeModeRel)
+	r.SeekMillis(1500, chubby.SeekModeForward)
+	r.Fail("Next", fail)
+	if err := r.Next(); err != fail {
+		t.Fatalf("%v != %v", err, fail)
+	}
+	s, _ := r.Status()
+	if s.PlaylistPos != 0 {
+		t.Fatal("failed call was forwarded")
+	}
+	if s.TrackPosMillis != 1500 {
+		t.Fatalf("unexpected position: %d", s.TrackPosMillis)
+	}
+
+	exp := []Call{
+		{"Play", []interface{}{"/a"}},
+		{"Volume", []interface{}{10, chubby.VolumeMode(true)}},
+		{"SeekMillis", []interface{}{time.Millis(1500), chubby.SeekModeForward}},
+		{"Next", nil},
+		{"Status", nil},
+	}
+	if !reflect.DeepEqual(exp, r.Calls()) {
+		t.Fatalf("%v != %v", exp, r.Calls())
+	}
+}
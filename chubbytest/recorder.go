@@ -0,0 +1,285 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubbytest
+
+import (
+	"sync"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Recorder is a chubby.Client which records all calls made to it.
+// Calls are forwarded to the wrapped client, if any, otherwise zero
+// values are returned. Errors can be injected per method with Fail.
+type Recorder struct {
+	client chubby.Client
+	mu     sync.Mutex
+	calls  []Call
+	errs   map[string]error
+}
+
+var _ chubby.Client = (*Recorder)(nil)
+
+func NewRecorder(client chubby.Client) *Recorder {
+	return &Recorder{client: client, errs: make(map[string]error)}
+}
+
+// Fail makes all subsequent calls of the given method return err.
+// Nil err removes previously injected error.
+func (r *Recorder) Fail(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		delete(r.errs, method)
+	} else {
+		r.errs[method] = err
+	}
+}
+
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make([]Call, len(r.calls))
+	copy(calls, r.calls)
+
+	return calls
+}
+
+// Methods returns names of all recorded calls in order.
+func (r *Recorder) Methods() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ms := make([]string, len(r.calls))
+	for i, c := range r.calls {
+		ms[i] = c.Method
+	}
+
+	return ms
+}
+
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
+
+func (r *Recorder) CreatePlaylist(name string) error {
+	if err := r.record("CreatePlaylist", name); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.CreatePlaylist(name)
+}
+
+func (r *Recorder) DeletePlaylist(name string) error {
+	if err := r.record("DeletePlaylist", name); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.DeletePlaylist(name)
+}
+
+func (r *Recorder) Events(enable bool) (<-chan chubby.Event, error) {
+	if err := r.record("Events", enable); err != nil {
+		return nil, err
+	}
+	if r.client == nil {
+		return nil, nil
+	}
+
+	return r.client.Events(enable)
+}
+
+func (r *Recorder) Kill() error {
+	if err := r.record("Kill"); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Kill()
+}
+
+func (r *Recorder) List(path string) ([]chubby.Entry, error) {
+	if err := r.record("List", path); err != nil {
+		return nil, err
+	}
+	if r.client == nil {
+		return nil, nil
+	}
+
+	return r.client.List(path)
+}
+
+func (r *Recorder) Next() error {
+	if err := r.record("Next"); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Next()
+}
+
+func (r *Recorder) Pause() error {
+	if err := r.record("Pause"); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Pause()
+}
+
+func (r *Recorder) Ping() error {
+	if err := r.record("Ping"); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Ping()
+}
+
+func (r *Recorder) Play(pth string) error {
+	if err := r.record("Play", pth); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Play(pth)
+}
+
+func (r *Recorder) Playlists() ([]*chubby.Playlist, error) {
+	if err := r.record("Playlists"); err != nil {
+		return nil, err
+	}
+	if r.client == nil {
+		return nil, nil
+	}
+
+	return r.client.Playlists()
+}
+
+func (r *Recorder) Prev() error {
+	if err := r.record("Prev"); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Prev()
+}
+
+func (r *Recorder) RenamePlaylist(from, to string) error {
+	if err := r.record("RenamePlaylist", from, to); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.RenamePlaylist(from, to)
+}
+
+func (r *Recorder) Seek(t time.Time, mode chubby.SeekMode) error {
+	if err := r.record("Seek", t, mode); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Seek(t, mode)
+}
+
+func (r *Recorder) SeekMillis(t time.Millis, mode chubby.SeekMode) error {
+	if err := r.record("SeekMillis", t, mode); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.SeekMillis(t, mode)
+}
+
+func (r *Recorder) Status() (*chubby.Status, error) {
+	if err := r.record("Status"); err != nil {
+		return nil, err
+	}
+	if r.client == nil {
+		return &chubby.Status{State: chubby.StateStopped}, nil
+	}
+
+	return r.client.Status()
+}
+
+func (r *Recorder) Stop() error {
+	if err := r.record("Stop"); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Stop()
+}
+
+func (r *Recorder) Volume(vol int, mode chubby.VolumeMode) error {
+	if err := r.record("Volume", vol, mode); err != nil {
+		return err
+	}
+	if r.client == nil {
+		return nil
+	}
+
+	return r.client.Volume(vol, mode)
+}
+
+func (r *Recorder) record(method string, args ...interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+
+	return r.errs[method]
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import "github.com/vchimishuk/chubby/time"
+
+// Client is the set of commands supported by the server. It is
+// implemented by Chubby and by test doubles from the chubbytest package.
+type Client interface {
+	CreatePlaylist(name string) error
+	DeletePlaylist(name string) error
+	Events(enable bool) (<-chan Event, error)
+	Kill() error
+	List(path string) ([]Entry, error)
+	Next() error
+	Pause() error
+	Ping() error
+	Play(pth string) error
+	Playlists() ([]*Playlist, error)
+	Prev() error
+	RenamePlaylist(from, to string) error
+	Seek(time time.Time, mode SeekMode) error
+	SeekMillis(t time.Millis, mode SeekMode) error
+	Status() (*Status, error)
+	Stop() error
+	Volume(vol int, mode VolumeMode) error
+}
+
+var _ Client = (*Chubby)(nil)
@@ -0,0 +1,212 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"sync"
+	stdtime "time"
+
+	"github.com/vchimishuk/chubby/time"
+)
+
+// VolumeControl coalesces frequent volume changes, e.g. produced
+// by a slider, and sends them to the server at most once per interval.
+// Absolute changes override pending ones, relative changes are summed up.
+type VolumeControl struct {
+	t *throttle
+}
+
+func NewVolumeControl(c Client, interval stdtime.Duration) *VolumeControl {
+	return &VolumeControl{newThrottle(interval,
+		func(a adjustment) error {
+			return c.Volume(a.value, VolumeMode(!a.abs))
+		},
+		func() (int, error) {
+			s, err := c.Status()
+			if err != nil {
+				return 0, err
+			}
+			return s.Volume, nil
+		})}
+}
+
+func (v *VolumeControl) Set(vol int, mode VolumeMode) {
+	v.t.set(adjustment{abs: mode == VolumeModeAbs, value: vol, set: true})
+}
+
+// Wait waits until all pending changes are sent and returns the volume
+// reported by the server afterwards. The error of the last failed
+// command, if any, is returned as well.
+func (v *VolumeControl) Wait() (int, error) {
+	return v.t.wait()
+}
+
+// SeekControl is like VolumeControl for track position.
+type SeekControl struct {
+	t *throttle
+}
+
+func NewSeekControl(c Client, interval stdtime.Duration) *SeekControl {
+	return &SeekControl{newThrottle(interval,
+		func(a adjustment) error {
+			if a.abs {
+				return c.Seek(time.Time(a.value), SeekModeAbs)
+			} else if a.value < 0 {
+				return c.Seek(time.Time(-a.value), SeekModeBackward)
+			} else {
+				return c.Seek(time.Time(a.value), SeekModeForward)
+			}
+		},
+		func() (int, error) {
+			s, err := c.Status()
+			if err != nil {
+				return 0, err
+			}
+			return int(s.TrackPos), nil
+		})}
+}
+
+func (s *SeekControl) Set(t time.Time, mode SeekMode) {
+	a := adjustment{value: int(t), set: true}
+	switch mode {
+	case SeekModeAbs:
+		a.abs = true
+	case SeekModeBackward:
+		a.value = -a.value
+	case SeekModeForward:
+	default:
+		panic("unsupported SeekMode")
+	}
+	s.t.set(a)
+}
+
+// Wait is like VolumeControl.Wait but returns the track position.
+func (s *SeekControl) Wait() (time.Time, error) {
+	v, err := s.t.wait()
+
+	return time.Time(v), err
+}
+
+type adjustment struct {
+	abs   bool
+	value int
+	set   bool
+}
+
+func (a adjustment) merge(b adjustment) adjustment {
+	if !a.set || b.abs {
+		return b
+	}
+	a.value += b.value
+
+	return a
+}
+
+type throttle struct {
+	interval stdtime.Duration
+	send     func(a adjustment) error
+	query    func() (int, error)
+	mu       sync.Mutex
+	pending  adjustment
+	running  bool
+	last     stdtime.Time
+	err      error
+	waiters  []chan result
+}
+
+type result struct {
+	value int
+	err   error
+}
+
+func newThrottle(interval stdtime.Duration, send func(adjustment) error,
+	query func() (int, error)) *throttle {
+
+	return &throttle{interval: interval, send: send, query: query}
+}
+
+func (t *throttle) set(a adjustment) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = t.pending.merge(a)
+	if !t.running {
+		t.running = true
+		go t.run()
+	}
+}
+
+func (t *throttle) wait() (int, error) {
+	t.mu.Lock()
+	if !t.running {
+		t.mu.Unlock()
+		return t.query()
+	}
+	ch := make(chan result, 1)
+	t.waiters = append(t.waiters, ch)
+	t.mu.Unlock()
+
+	r := <-ch
+
+	return r.value, r.err
+}
+
+func (t *throttle) run() {
+	for {
+		t.mu.Lock()
+		d := stdtime.Until(t.last.Add(t.interval))
+		t.mu.Unlock()
+		stdtime.Sleep(d)
+
+		t.mu.Lock()
+		a := t.pending
+		t.pending = adjustment{}
+		t.last = stdtime.Now()
+		t.mu.Unlock()
+
+		if a.set {
+			if err := t.send(a); err != nil {
+				t.mu.Lock()
+				t.err = err
+				t.mu.Unlock()
+			}
+			continue
+		}
+
+		// Nothing left to send, report the result.
+		v, err := t.query()
+		t.mu.Lock()
+		if t.pending.set {
+			t.mu.Unlock()
+			continue
+		}
+		if t.err != nil {
+			err = t.err
+			t.err = nil
+		}
+		waiters := t.waiters
+		t.waiters = nil
+		t.running = false
+		t.mu.Unlock()
+
+		for _, w := range waiters {
+			w <- result{v, err}
+		}
+		return
+	}
+}
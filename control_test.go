@@ -0,0 +1,73 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/chubbytest"
+)
+
+func TestVolumeControl(t *testing.T) {
+	rec := chubbytest.NewRecorder(chubbytest.NewPlayer())
+	vc := chubby.NewVolumeControl(rec, 20*time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		vc.Set(1, chubby.VolumeModeRel)
+	}
+	vc.Set(30, chubby.VolumeModeAbs)
+	vc.Set(5, chubby.VolumeModeRel)
+	vc.Set(-2, chubby.VolumeModeRel)
+	vol, err := vc.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vol != 33 {
+		t.Fatalf("%d != 33", vol)
+	}
+
+	n := 0
+	for _, m := range rec.Methods() {
+		if m == "Volume" {
+			n++
+		}
+	}
+	if n == 0 || n > 3 {
+		t.Fatalf("unexpected number of volume calls: %d", n)
+	}
+}
+
+func TestSeekControl(t *testing.T) {
+	p := chubbytest.NewPlayer()
+	p.AddTrack(&chubby.Track{Path: "/a.flac", Length: 600})
+	p.Play("/a.flac")
+	sc := chubby.NewSeekControl(p, 10*time.Millisecond)
+
+	sc.Set(100, chubby.SeekModeAbs)
+	sc.Set(30, chubby.SeekModeForward)
+	sc.Set(10, chubby.SeekModeBackward)
+	pos, err := sc.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 120 {
+		t.Fatalf("%d != 120", pos)
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2023 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+// Copyright 2023-2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
 //
 // This file is part of chubby.
 //
@@ -17,7 +17,12 @@
 
 package chubby
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vchimishuk/chubby/parser"
+)
 
 type ServerError struct {
 	msg string
@@ -27,7 +32,10 @@ func (e ServerError) Error() string {
 	return e.msg
 }
 
-func newServerError(msg string) ServerError {
+// NewServerError creates the error the server replies with. It lets
+// other Client implementations, such as test doubles, fail the same
+// way the server does, so callers can rely on IsServerError.
+func NewServerError(msg string) ServerError {
 	return ServerError{msg}
 }
 
@@ -36,3 +44,56 @@ func IsServerError(err error) bool {
 
 	return errors.As(err, &c)
 }
+
+type ProtocolError struct {
+	// Command in flight when the error occurred, if any.
+	Command string
+	// Raw header or body line received from the server.
+	Line string
+	// Byte offset in Line where parsing failed or -1 if unknown.
+	Offset int
+	// Usable reports if the connection is still in a consistent
+	// state and can be used for the next command.
+	Usable bool
+	Err    error
+}
+
+func (e *ProtocolError) Error() string {
+	s := "protocol: " + e.Err.Error()
+	if e.Command != "" {
+		s += fmt.Sprintf(" (command %s)", e.Command)
+	}
+	if e.Line != "" {
+		s += fmt.Sprintf(": %q", e.Line)
+	}
+
+	return s
+}
+
+func (e *ProtocolError) Unwrap() error {
+	return e.Err
+}
+
+func newProtocolError(cmd string, line string, usable bool,
+	err error) *ProtocolError {
+
+	off := -1
+	var perr *parser.Error
+	if errors.As(err, &perr) {
+		off = perr.Position
+	}
+
+	return &ProtocolError{
+		Command: cmd,
+		Line:    line,
+		Offset:  off,
+		Usable:  usable,
+		Err:     err,
+	}
+}
+
+func IsProtocolError(err error) bool {
+	var e *ProtocolError
+
+	return errors.As(err, &e)
+}
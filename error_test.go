@@ -0,0 +1,123 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vchimishuk/chubby/parser"
+)
+
+const malformedEntry = `type: "track", path: "/a.flac", year: ?`
+
+// malformedServer answers list with a malformed entry, playlists
+// with a malformed header and every other command with an empty
+// successful response.
+func malformedServer(t *testing.T) (int, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var conn net.Conn
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		conn = cn
+		mu.Unlock()
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			var resp string
+			switch strings.Fields(line)[0] {
+			case "list":
+				resp = "OK\n" + malformedEntry + "\n\n"
+			case "playlists":
+				resp = "WAT\n"
+			case "status":
+				resp = "OK\nstate: \"stopped\", volume: 100\n\n"
+			default:
+				resp = "OK\n\n"
+			}
+			if _, err := conn.Write([]byte(resp)); err != nil {
+				return
+			}
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, func() {
+		ln.Close()
+		mu.Lock()
+		if conn != nil {
+			conn.Close()
+		}
+		mu.Unlock()
+		wg.Wait()
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	port, stop := malformedServer(t)
+	defer stop()
+	c := &Chubby{}
+	if err := c.Connect("127.0.0.1", port); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err := c.List("/")
+	var perr *ProtocolError
+	if !errors.As(err, &perr) || !IsProtocolError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if perr.Command != "list" || perr.Line != malformedEntry ||
+		perr.Offset != strings.Index(malformedEntry, "?") || !perr.Usable {
+		t.Fatalf("unexpected error: %+v", perr)
+	}
+	var parr *parser.Error
+	if !errors.As(err, &parr) {
+		t.Fatalf("parser error is not wrapped: %v", err)
+	}
+	// Connection stays usable after a malformed body.
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Playlists()
+	if !errors.As(err, &perr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if perr.Command != "playlists" || perr.Line != "WAT" ||
+		perr.Offset != -1 || perr.Usable {
+		t.Fatalf("unexpected error: %+v", perr)
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright 2018-2023 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+// Copyright 2018-2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
 //
 // This file is part of chubby.
 //
@@ -20,6 +20,8 @@ package chubby
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/vchimishuk/chubby/parser"
 	"github.com/vchimishuk/chubby/time"
@@ -40,6 +42,10 @@ func (e *CreatePlaylistEvent) Event() string {
 }
 
 func (e *CreatePlaylistEvent) Serialize() string {
+	if e.s == "" {
+		return serialize("name", e.Name)
+	}
+
 	return e.s
 }
 
@@ -53,6 +59,10 @@ func (e *DeletePlaylistEvent) Event() string {
 }
 
 func (e *DeletePlaylistEvent) Serialize() string {
+	if e.s == "" {
+		return serialize("name", e.Name)
+	}
+
 	return e.s
 }
 
@@ -62,27 +72,115 @@ type StatusEvent struct {
 	Volume      int
 	PlaylistPos int
 	TrackPos    time.Time
-	Playlist    *Playlist
-	Track       *Track
+	// TrackPosMillis is the same as Status.TrackPosMillis.
+	TrackPosMillis time.Millis
+	Playlist       *Playlist
+	Track          *Track
 }
 
 func (e *StatusEvent) Event() string {
 	return "status"
 }
 
+// Serialize returns the event body as it was received or, for events
+// created by the user, in the same form the server sends it.
 func (e *StatusEvent) Serialize() string {
+	if e.s != "" {
+		return e.s
+	}
+
+	kv := []interface{}{"state", string(e.State), "volume", e.Volume}
+	if e.State != StateStopped {
+		pos := e.TrackPosMillis
+		if pos == 0 {
+			pos = e.TrackPos.Millis()
+		}
+		kv = append(kv, "playlist-position", e.PlaylistPos,
+			"track-position", pos)
+		if pl := e.Playlist; pl != nil {
+			kv = append(kv, "playlist-name", pl.Name,
+				"playlist-duration", int(pl.Duration),
+				"playlist-length", pl.Length)
+		}
+		if t := e.Track; t != nil {
+			length := t.LengthMillis
+			if length == 0 {
+				length = t.Length.Millis()
+			}
+			kv = append(kv, "track-path", t.Path,
+				"track-artist", t.Artist,
+				"track-album", t.Album,
+				"track-year", t.Year,
+				"track-title", t.Title,
+				"track-number", t.Number,
+				"track-length", length)
+		}
+	}
+
+	return serialize(kv...)
+}
+
+// ErrorEvent is delivered in place of an event which
+// was received from the server but could not be parsed.
+type ErrorEvent struct {
+	s   string
+	Err *ProtocolError
+}
+
+func (e *ErrorEvent) Event() string {
+	return "error"
+}
+
+func (e *ErrorEvent) Serialize() string {
 	return e.s
 }
 
+// serialize formats key-value pairs the way the server does.
+// Millisecond values are written as fractional seconds.
+func serialize(kv ...interface{}) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(kv); i += 2 {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(kv[i].(string))
+		b.WriteString(": ")
+		switch v := kv[i+1].(type) {
+		case string:
+			b.WriteByte('"')
+			for _, r := range v {
+				if r == '"' || r == '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteRune(r)
+			}
+			b.WriteByte('"')
+		case time.Millis:
+			if v%1000 == 0 {
+				fmt.Fprintf(&b, "%d", v/1000)
+			} else {
+				b.WriteString(strconv.FormatFloat(v.Seconds(), 'f', -1, 64))
+			}
+		default:
+			fmt.Fprint(&b, v)
+		}
+	}
+
+	return b.String()
+}
+
+var errUnknownEvent = errors.New("unknown event")
+
 func parseEvent(name string, lines []string) (Event, error) {
 	if len(lines) != 1 {
-		return nil, errors.New("protocol error")
+		return nil, newProtocolError("", strings.Join(lines, "\n"),
+			true, errors.New("invalid event body"))
 	}
 	s := lines[0]
 
 	p, err := parser.Parse(s)
 	if err != nil {
-		return nil, fmt.Errorf("protocol: %w", err)
+		return nil, newProtocolError("", s, true, err)
 	}
 
 	switch name {
@@ -93,54 +191,38 @@ func parseEvent(name string, lines []string) (Event, error) {
 	case "status":
 		return createStatus(s, p)
 	default:
-		return nil, fmt.Errorf("protocol: invalid event: %s", name)
+		return nil, fmt.Errorf("%w: %s", errUnknownEvent, name)
 	}
 }
 
 func createCreatePlaylist(s string, m map[string]any) (Event, error) {
 	return &CreatePlaylistEvent{
 		s:    s,
-		Name: m["name"].(string),
+		Name: str(m, "name"),
 	}, nil
 }
 
 func createDeletePlaylist(s string, m map[string]any) (Event, error) {
 	return &DeletePlaylistEvent{
 		s:    s,
-		Name: m["name"].(string),
+		Name: str(m, "name"),
 	}, nil
 }
 
 func createStatus(s string, m map[string]any) (Event, error) {
-	state, err := parseState(m["state"].(string))
+	st, err := parseStatus(m, parseMillis(s))
 	if err != nil {
 		return nil, err
 	}
 
-	e := &StatusEvent{
-		s:      s,
-		State:  state,
-		Volume: m["volume"].(int),
-	}
-
-	if state != StateStopped {
-		e.PlaylistPos = m["playlist-position"].(int)
-		e.TrackPos = time.Time(m["track-position"].(int))
-		e.Playlist = &Playlist{
-			Name:     m["playlist-name"].(string),
-			Duration: time.Time(m["playlist-duration"].(int)),
-			Length:   m["playlist-length"].(int),
-		}
-		e.Track = &Track{
-			Path:   m["track-path"].(string),
-			Artist: m["track-artist"].(string),
-			Album:  m["track-album"].(string),
-			Year:   m["track-year"].(int),
-			Title:  m["track-title"].(string),
-			Number: m["track-number"].(int),
-			Length: time.Time(m["track-length"].(int)),
-		}
-	}
-
-	return e, nil
+	return &StatusEvent{
+		s:              s,
+		State:          st.State,
+		Volume:         st.Volume,
+		PlaylistPos:    st.PlaylistPos,
+		TrackPos:       st.TrackPos,
+		TrackPosMillis: st.TrackPosMillis,
+		Playlist:       st.Playlist,
+		Track:          st.Track,
+	}, nil
 }
@@ -0,0 +1,281 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+	stdtime "time"
+)
+
+var (
+	errNotDir = errors.New("not a directory")
+	errIsDir  = errors.New("is a directory")
+)
+
+// FS is a read-only fs.FS view of the library. Library directories
+// are directories and tracks are empty files whose FileInfo.Sys returns
+// the *Track. FS names are relative to the library root, so "a/b.flac"
+// is the "/a/b.flac" track. Directory listings are cached until Reset
+// is called.
+type FS struct {
+	l     Lister
+	mu    sync.Mutex
+	cache map[string][]Entry
+}
+
+var (
+	_ fs.ReadDirFS = (*FS)(nil)
+	_ fs.StatFS    = (*FS)(nil)
+)
+
+func NewFS(l Lister) *FS {
+	return &FS{l: l, cache: make(map[string][]Entry)}
+}
+
+// Reset drops all cached listings.
+func (f *FS) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.cache = make(map[string][]Entry)
+}
+
+func (f *FS) Open(name string) (fs.File, error) {
+	e, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := &fileInfo{name: path.Base(name), e: e}
+	if e.IsDir() {
+		return &dirFile{fs: f, name: name, info: info}, nil
+	}
+
+	return &file{info: info}, nil
+}
+
+// ReadDir returns the directory entries sorted by file name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+	entries, err := f.list(fsPath(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	des := make([]fs.DirEntry, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		n := entryName(e)
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		des = append(des, fs.FileInfoToDirEntry(&fileInfo{name: n, e: e}))
+	}
+	sort.Slice(des, func(i, j int) bool {
+		return des[i].Name() < des[j].Name()
+	})
+
+	return des, nil
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	e, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileInfo{name: path.Base(name), e: e}, nil
+}
+
+// lookup finds the entry by listing all its parent directories.
+func (f *FS) lookup(op, name string) (Entry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &Dir{Path: "/", Name: "/"}, nil
+	}
+
+	parent, err := f.lookup(op, path.Dir(name))
+	if err != nil {
+		return nil, err
+	}
+	if !parent.IsDir() {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := f.list(fsPath(path.Dir(name)))
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	base := path.Base(name)
+	for _, e := range entries {
+		if entryName(e) == base {
+			return e, nil
+		}
+	}
+
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+func (f *FS) list(pth string) ([]Entry, error) {
+	f.mu.Lock()
+	entries, ok := f.cache[pth]
+	f.mu.Unlock()
+	if ok {
+		return entries, nil
+	}
+
+	entries, err := f.l.List(pth)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.cache[pth] = entries
+	f.mu.Unlock()
+
+	return entries, nil
+}
+
+// fsPath converts FS name to the library path.
+func fsPath(name string) string {
+	return path.Join("/", name)
+}
+
+// entryName returns the name of the entry inside its directory.
+func entryName(e Entry) string {
+	if e.IsDir() {
+		return path.Base(path.Clean(e.Dir().Path))
+	}
+
+	return path.Base(path.Clean(e.Track().Path))
+}
+
+type fileInfo struct {
+	name string
+	e    Entry
+}
+
+func (i *fileInfo) Name() string {
+	return i.name
+}
+
+func (i *fileInfo) Size() int64 {
+	return 0
+}
+
+func (i *fileInfo) Mode() fs.FileMode {
+	if i.e.IsDir() {
+		return fs.ModeDir | 0555
+	}
+
+	return 0444
+}
+
+func (i *fileInfo) ModTime() stdtime.Time {
+	return stdtime.Time{}
+}
+
+func (i *fileInfo) IsDir() bool {
+	return i.e.IsDir()
+}
+
+// Sys returns *Dir or *Track.
+func (i *fileInfo) Sys() interface{} {
+	if i.e.IsDir() {
+		return i.e.Dir()
+	}
+
+	return i.e.Track()
+}
+
+// file is an opened track. Track contents are not available,
+// so it is always empty.
+type file struct {
+	info *fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Read(b []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (f *file) Close() error {
+	return nil
+}
+
+type dirFile struct {
+	fs      *FS
+	name    string
+	info    *fileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dirFile) Read(b []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errIsDir}
+}
+
+func (d *dirFile) Close() error {
+	return nil
+}
+
+// ReadDir follows the fs.ReadDirFile semantics.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+
+	if n <= 0 {
+		res := d.entries
+		d.entries = nil
+		return res, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	res := d.entries[:n]
+	d.entries = d.entries[n:]
+
+	return res, nil
+}
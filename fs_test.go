@@ -0,0 +1,143 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/chubbytest"
+)
+
+func newLibrary() *chubbytest.Player {
+	p := chubbytest.NewPlayer()
+	for _, t := range []*chubby.Track{
+		{Path: "/music/Pink Floyd/1977 - Animals/01 - Pigs on the Wing.flac",
+			Artist: "Pink Floyd", Album: "Animals", Year: 1977,
+			Title: "Pigs on the Wing", Number: 1, Length: 85},
+		{Path: "/music/Pink Floyd/1977 - Animals/02 - Dogs.flac",
+			Artist: "Pink Floyd", Album: "Animals", Year: 1977,
+			Title: "Dogs", Number: 2, Length: 1024},
+		{Path: "/music/Kraftwerk/1974 - Autobahn/01 - Autobahn.flac",
+			Artist: "Kraftwerk", Album: "Autobahn", Year: 1974,
+			Title: "Autobahn", Number: 1, Length: 1368},
+		{Path: "/music/single.mp3", Title: "Single", Length: 200},
+	} {
+		p.AddTrack(t)
+	}
+
+	return p
+}
+
+func TestFS(t *testing.T) {
+	fsys := chubby.NewFS(newLibrary())
+
+	err := fstest.TestFS(fsys,
+		"music/Pink Floyd/1977 - Animals/02 - Dogs.flac",
+		"music/Kraftwerk/1974 - Autobahn/01 - Autobahn.flac",
+		"music/single.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := fs.Glob(fsys, "music/*/*/01 - *.flac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 ||
+		matches[0] != "music/Kraftwerk/1974 - Autobahn/01 - Autobahn.flac" {
+		t.Fatalf("unexpected matches: %v", matches)
+	}
+
+	info, err := fs.Stat(fsys, "music/Pink Floyd/1977 - Animals/02 - Dogs.flac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, ok := info.Sys().(*chubby.Track)
+	if !ok || tr.Title != "Dogs" || tr.Number != 2 {
+		t.Fatalf("unexpected track: %v", info.Sys())
+	}
+	info, err = fs.Stat(fsys, "music/Kraftwerk")
+	if err != nil || !info.IsDir() || info.Sys().(*chubby.Dir).Path != "/music/Kraftwerk" {
+		t.Fatalf("unexpected dir: %v, %v", info, err)
+	}
+}
+
+func TestFSErrors(t *testing.T) {
+	fsys := chubby.NewFS(newLibrary())
+
+	for _, name := range []string{"missing", "music/missing/a.flac",
+		"music/single.mp3/a"} {
+
+		_, err := fsys.Open(name)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"/music", "music/", "music/../music", ""} {
+		_, err := fsys.Open(name)
+		if !errors.Is(err, fs.ErrInvalid) {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := fsys.ReadDir("music/single.mp3"); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestFSCache(t *testing.T) {
+	p := newLibrary()
+	rec := chubbytest.NewRecorder(p)
+	fsys := chubby.NewFS(rec)
+
+	walk := func() int {
+		n := 0
+		err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			n++
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if n := walk(); n != 10 {
+		t.Fatalf("unexpected number of entries: %d", n)
+	}
+	calls := len(rec.Calls())
+	if calls != 6 {
+		t.Fatalf("unexpected number of listings: %d", calls)
+	}
+	walk()
+	if len(rec.Calls()) != calls {
+		t.Fatal("listings are not cached")
+	}
+
+	p.AddTrack(&chubby.Track{Path: "/music/new.flac"})
+	if n := walk(); n != 10 {
+		t.Fatalf("unexpected number of entries: %d", n)
+	}
+	fsys.Reset()
+	if n := walk(); n != 11 {
+		t.Fatalf("unexpected number of entries: %d", n)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrHeartbeat = errors.New("heartbeat timeout")
+
+// RTT returns the round-trip time measured by the last answered
+// keepalive ping or zero if there were none.
+func (c *Chubby) RTT() time.Duration {
+	s := c.sess.Load()
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(s.rtt.Load())
+}
+
+// Healthy reports if the client is connected and the last keepalive
+// ping, if any, was answered in time.
+func (c *Chubby) Healthy() bool {
+	s := c.sess.Load()
+
+	return s != nil && !s.closed() && s.missed.Load() == 0
+}
+
+func (s *session) heartbeat(interval time.Duration, misses int) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		seen := time.Unix(0, s.seen.Load())
+		if time.Since(seen) < interval {
+			continue
+		}
+
+		start := time.Now()
+		cl := newCall(cmdPing)
+		if s.send(cl) != nil {
+			return
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-s.done:
+			timer.Stop()
+			return
+		case <-cl.done:
+			timer.Stop()
+			// Any answer, even an error, means the server is alive.
+			s.rtt.Store(int64(time.Since(start)))
+			s.missed.Store(0)
+		case <-timer.C:
+			if int(s.missed.Add(1)) >= misses {
+				s.shutdown(ErrHeartbeat)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package fold
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	for s, f := range map[string]string{
+		"Björk":        "bjork",
+		"Motörhead":    "motorhead",
+		"Sigur Rós":    "sigur ros",
+		"Dvořák":       "dvorak",
+		"Straße":       "strasse",
+		"Ænima":        "aenima",
+		"Кино":         "кино",
+		"Café Tacvba": "cafe tacvba",
+		"Pink Floyd":   "pink floyd",
+		"":             "",
+	} {
+		if r := String(s); r != f {
+			t.Fatalf("%s: %s != %s", s, r, f)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	f := strings.Join(Fields("1977 - Animals / Pigs (Three Different Ones)"), ",")
+	if f != "1977,animals,pigs,three,different,ones" {
+		t.Fatalf("unexpected fields: %s", f)
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/internal/fold"
+	"github.com/vchimishuk/chubby/time"
+)
+
+// Gap is an album with missing track numbers.
+type Gap struct {
+	Album   *Album
+	Missing []int
+}
+
+// Variant is one of artist name spellings.
+type Variant struct {
+	Name string
+	// Tracks is the number of tracks using the spelling.
+	Tracks int
+}
+
+// MissingFields is a track with empty metadata fields.
+type MissingFields struct {
+	Track *chubby.Track
+	// Fields are names of the empty fields: "artist", "album",
+	// "year", "title" and "number".
+	Fields []string
+}
+
+// Report is the library metadata quality report.
+type Report struct {
+	// Duplicates are groups of tracks with the same artist and title,
+	// compared case and diacritic insensitively, and lengths within
+	// the tolerance.
+	Duplicates [][]*chubby.Track
+	// Gaps are albums with missing track numbers.
+	Gaps []Gap
+	// Spellings are groups of artist names which differ only in
+	// case, diacritics, spaces, punctuation or "The" prefix. Variants
+	// are ordered by the number of tracks, most used first.
+	Spellings [][]Variant
+	Missing   []MissingFields
+}
+
+// Analyze checks the library metadata. Lengths of duplicate tracks
+// can differ by tolerance at most.
+func Analyze(ix *Index, tolerance time.Time) *Report {
+	r := &Report{}
+	r.findDuplicates(ix, tolerance)
+	r.findGaps(ix)
+	r.findSpellings(ix)
+	r.findMissing(ix)
+
+	return r
+}
+
+// Empty reports whether no problems were found.
+func (r *Report) Empty() bool {
+	return len(r.Duplicates) == 0 && len(r.Gaps) == 0 &&
+		len(r.Spellings) == 0 && len(r.Missing) == 0
+}
+
+func (r *Report) findDuplicates(ix *Index, tolerance time.Time) {
+	type key struct {
+		artist string
+		title  string
+	}
+	groups := make(map[key][]*chubby.Track)
+	var keys []key
+	for _, t := range ix.tracks {
+		if t.Title == "" {
+			continue
+		}
+		k := key{artistKey(t.Artist), strings.Join(fold.Fields(t.Title), " ")}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], t)
+	}
+
+	for _, k := range keys {
+		g := groups[k]
+		if len(g) < 2 {
+			continue
+		}
+		sort.SliceStable(g, func(i, j int) bool {
+			return g[i].Length < g[j].Length
+		})
+		// Tracks are chained if neighbour lengths are within tolerance.
+		start := 0
+		for i := 1; i <= len(g); i++ {
+			if i == len(g) || g[i].Length-g[i-1].Length > tolerance {
+				if i-start > 1 {
+					dup := append([]*chubby.Track(nil), g[start:i]...)
+					sort.Slice(dup, func(i, j int) bool {
+						return dup[i].Path < dup[j].Path
+					})
+					r.Duplicates = append(r.Duplicates, dup)
+				}
+				start = i
+			}
+		}
+	}
+	sort.Slice(r.Duplicates, func(i, j int) bool {
+		return r.Duplicates[i][0].Path < r.Duplicates[j][0].Path
+	})
+}
+
+func (r *Report) findGaps(ix *Index) {
+	for _, a := range ix.Albums(Query{}) {
+		last := 0
+		nums := make(map[int]bool)
+		for _, t := range a.Tracks {
+			nums[t.Number] = true
+			if t.Number > last {
+				last = t.Number
+			}
+		}
+		var missing []int
+		for n := 1; n < last; n++ {
+			if !nums[n] {
+				missing = append(missing, n)
+			}
+		}
+		if len(missing) > 0 {
+			r.Gaps = append(r.Gaps, Gap{Album: a, Missing: missing})
+		}
+	}
+}
+
+func (r *Report) findSpellings(ix *Index) {
+	variants := make(map[string]map[string]int)
+	var keys []string
+	for _, t := range ix.tracks {
+		if t.Artist == "" {
+			continue
+		}
+		k := artistKey(t.Artist)
+		if variants[k] == nil {
+			variants[k] = make(map[string]int)
+			keys = append(keys, k)
+		}
+		variants[k][t.Artist]++
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if len(variants[k]) < 2 {
+			continue
+		}
+		var vs []Variant
+		for n, c := range variants[k] {
+			vs = append(vs, Variant{Name: n, Tracks: c})
+		}
+		sort.Slice(vs, func(i, j int) bool {
+			if vs[i].Tracks != vs[j].Tracks {
+				return vs[i].Tracks > vs[j].Tracks
+			}
+			return vs[i].Name < vs[j].Name
+		})
+		r.Spellings = append(r.Spellings, vs)
+	}
+}
+
+func (r *Report) findMissing(ix *Index) {
+	for _, t := range ix.tracks {
+		var fs []string
+		if t.Artist == "" {
+			fs = append(fs, "artist")
+		}
+		if t.Album == "" {
+			fs = append(fs, "album")
+		}
+		if t.Year == 0 {
+			fs = append(fs, "year")
+		}
+		if t.Title == "" {
+			fs = append(fs, "title")
+		}
+		if t.Number == 0 {
+			fs = append(fs, "number")
+		}
+		if len(fs) > 0 {
+			r.Missing = append(r.Missing, MissingFields{t, fs})
+		}
+	}
+}
+
+// artistKey returns the artist name normalized for comparison,
+// so "The Beatles", "Beatles" and "the beatles" have the same key.
+func artistKey(s string) string {
+	ws := fold.Fields(s)
+	if len(ws) > 1 && ws[0] == "the" {
+		ws = ws[1:]
+	}
+
+	return strings.Join(ws, "")
+}
+
+// WriteText writes the report as a list of sections. Empty
+// sections are omitted.
+func (r *Report) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	sep := ""
+	section := func(title string) {
+		bw.WriteString(sep + title + ":\n")
+		sep = "\n"
+	}
+
+	if len(r.Duplicates) > 0 {
+		section("Duplicate tracks")
+		for _, d := range r.Duplicates {
+			fmt.Fprintf(bw, "  %s - %s\n", d[0].Artist, d[0].Title)
+			for _, t := range d {
+				fmt.Fprintf(bw, "    %s (%s)\n", t.Path, t.Length)
+			}
+		}
+	}
+	if len(r.Gaps) > 0 {
+		section("Missing track numbers")
+		for _, g := range r.Gaps {
+			ns := make([]string, len(g.Missing))
+			for i, n := range g.Missing {
+				ns[i] = fmt.Sprint(n)
+			}
+			fmt.Fprintf(bw, "  %s: %s\n", g.Album.Path, strings.Join(ns, ", "))
+		}
+	}
+	if len(r.Spellings) > 0 {
+		section("Inconsistent artist spellings")
+		for _, vs := range r.Spellings {
+			ss := make([]string, len(vs))
+			for i, v := range vs {
+				ss[i] = fmt.Sprintf("%q (%d)", v.Name, v.Tracks)
+			}
+			fmt.Fprintf(bw, "  %s\n", strings.Join(ss, ", "))
+		}
+	}
+	if len(r.Missing) > 0 {
+		section("Missing fields")
+		for _, m := range r.Missing {
+			fmt.Fprintf(bw, "  %s: %s\n", m.Track.Path,
+				strings.Join(m.Fields, ", "))
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vchimishuk/chubby"
+)
+
+func TestAnalyze(t *testing.T) {
+	tracks := append([]*chubby.Track(nil), testTracks...)
+	tracks = append(tracks,
+		&chubby.Track{Path: "/music/Best of/01 - Money.flac",
+			Artist: "pink floyd", Album: "Best of", Year: 2001,
+			Title: "Money", Number: 1, Length: 383},
+		&chubby.Track{Path: "/music/Best of/02 - Money.flac",
+			Artist: "The Pink Floyd", Album: "Best of", Year: 2001,
+			Title: "Money", Number: 2, Length: 400},
+		&chubby.Track{Path: "/music/Bjork/Debut/01 - Human Behaviour.flac",
+			Artist: "Bjork", Album: "Debut", Year: 1993,
+			Title: "Human Behaviour", Number: 1, Length: 252},
+	)
+	ix, err := Build(context.Background(), newTestPlayer(tracks), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := Analyze(ix, 2)
+	if r.Empty() {
+		t.Fatal("report is empty")
+	}
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	exp := `Duplicate tracks:
+  pink floyd - Money
+    /music/Best of/01 - Money.flac (6:23)
+    /music/Pink Floyd/1973 - The Dark Side of the Moon/05 - Money.flac (6:22)
+
+Missing track numbers:
+  /music/Pink Floyd/1973 - The Dark Side of the Moon: 2, 3, 4
+
+Inconsistent artist spellings:
+  "Bjork" (1), "Björk" (1)
+  "Pink Floyd" (5), "The Pink Floyd" (1), "pink floyd" (1)
+
+Missing fields:
+  /music/misc/unknown.mp3: artist, album, year, number
+`
+	if b.String() != exp {
+		t.Fatalf("unexpected report:\n%s", b.String())
+	}
+
+	r = Analyze(ix, 20)
+	if len(r.Duplicates) != 1 || len(r.Duplicates[0]) != 3 {
+		t.Fatalf("unexpected duplicates: %v", r.Duplicates)
+	}
+
+	r = Analyze(New(nil), 0)
+	b.Reset()
+	if err := r.WriteText(&b); err != nil || !r.Empty() || b.Len() != 0 {
+		t.Fatalf("unexpected report: %q, %v", b.String(), err)
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	stdtime "time"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+const (
+	cacheMagic = "chubby-library-cache"
+	// cacheVersion must be incremented on every incompatible
+	// change of the cache file format.
+	cacheVersion = 1
+)
+
+var (
+	ErrCacheFormat  = errors.New("invalid cache file")
+	ErrCacheVersion = errors.New("unsupported cache file version")
+	ErrNotCached    = errors.New("directory is not cached")
+)
+
+// ChangeKind is a kind of library entry change.
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a library entry change found by Cache.Refresh.
+type Change struct {
+	Kind ChangeKind
+	// Entry is the new entry, or the removed one for ChangeRemoved.
+	Entry chubby.Entry
+	// Old is the previous version of the modified entry.
+	Old chubby.Entry
+}
+
+// Cache is a persistent copy of the library tree. It implements
+// chubby.Lister, so cached library can be indexed, walked or used as
+// an FS without talking to the server. All methods are safe for
+// concurrent use, so Refresh can run in background.
+type Cache struct {
+	mu      sync.RWMutex
+	root    string
+	updated stdtime.Time
+	dirs    map[string][]chubby.Entry
+}
+
+var _ chubby.Lister = (*Cache)(nil)
+
+// NewCache returns an empty cache for the library tree rooted
+// at root. Use Refresh to fill it.
+func NewCache(root string) *Cache {
+	return &Cache{
+		root: path.Clean(root),
+		dirs: make(map[string][]chubby.Entry),
+	}
+}
+
+// LoadCache reads the cache file written by Save. ErrCacheVersion is
+// returned for files written by incompatible versions of the package,
+// which should be rebuilt.
+func LoadCache(file string) (*Cache, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadCache(bufio.NewReader(f))
+}
+
+// Save writes the cache to the file atomically.
+func (c *Cache) Save(file string) error {
+	f, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	err = c.Write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), file)
+}
+
+type cacheHeader struct {
+	Magic   string
+	Version int
+}
+
+type cacheBody struct {
+	Root    string
+	Updated stdtime.Time
+	Dirs    []cacheDir
+}
+
+type cacheDir struct {
+	Path    string
+	Entries []cacheEntry
+}
+
+type cacheEntry struct {
+	Dir          bool
+	Path         string
+	Name         string
+	Artist       string
+	Album        string
+	Year         int
+	Title        string
+	Number       int
+	Length       int
+	LengthMillis int64
+}
+
+// ReadCache reads the cache written by Write.
+func ReadCache(r io.Reader) (*Cache, error) {
+	dec := gob.NewDecoder(r)
+	var h cacheHeader
+	if err := dec.Decode(&h); err != nil || h.Magic != cacheMagic {
+		return nil, ErrCacheFormat
+	}
+	if h.Version != cacheVersion {
+		return nil, fmt.Errorf("%w: %d", ErrCacheVersion, h.Version)
+	}
+	var b cacheBody
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCacheFormat, err)
+	}
+
+	c := NewCache(b.Root)
+	c.updated = b.Updated
+	for _, d := range b.Dirs {
+		entries := make([]chubby.Entry, len(d.Entries))
+		for i, e := range d.Entries {
+			if e.Dir {
+				entries[i] = &chubby.Dir{Path: e.Path, Name: e.Name}
+			} else {
+				entries[i] = &chubby.Track{
+					Path:         e.Path,
+					Artist:       e.Artist,
+					Album:        e.Album,
+					Year:         e.Year,
+					Title:        e.Title,
+					Number:       e.Number,
+					Length:       time.Time(e.Length),
+					LengthMillis: time.Millis(e.LengthMillis),
+				}
+			}
+		}
+		c.dirs[d.Path] = entries
+	}
+
+	return c, nil
+}
+
+// Write writes the cache in the versioned binary format.
+func (c *Cache) Write(w io.Writer) error {
+	c.mu.RLock()
+	b := cacheBody{Root: c.root, Updated: c.updated}
+	for p, entries := range c.dirs {
+		d := cacheDir{Path: p, Entries: make([]cacheEntry, len(entries))}
+		for i, e := range entries {
+			if e.IsDir() {
+				d.Entries[i] = cacheEntry{Dir: true, Path: e.Dir().Path,
+					Name: e.Dir().Name}
+			} else {
+				t := e.Track()
+				d.Entries[i] = cacheEntry{
+					Path:         t.Path,
+					Artist:       t.Artist,
+					Album:        t.Album,
+					Year:         t.Year,
+					Title:        t.Title,
+					Number:       t.Number,
+					Length:       int(t.Length),
+					LengthMillis: int64(t.LengthMillis),
+				}
+			}
+		}
+		b.Dirs = append(b.Dirs, d)
+	}
+	c.mu.RUnlock()
+	sort.Slice(b.Dirs, func(i, j int) bool {
+		return b.Dirs[i].Path < b.Dirs[j].Path
+	})
+
+	enc := gob.NewEncoder(w)
+	if err := enc.Encode(cacheHeader{cacheMagic, cacheVersion}); err != nil {
+		return err
+	}
+
+	return enc.Encode(&b)
+}
+
+func (c *Cache) Root() string {
+	return c.root
+}
+
+// Updated returns the time of the last completed Refresh.
+func (c *Cache) Updated() stdtime.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.updated
+}
+
+// List returns the cached directory listing.
+func (c *Cache) List(pth string) ([]chubby.Entry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries, ok := c.dirs[path.Clean(pth)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", pth, ErrNotCached)
+	}
+
+	return append([]chubby.Entry(nil), entries...), nil
+}
+
+// Index returns the index of all cached entries.
+func (c *Cache) Index() *Index {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var all []chubby.Entry
+	for _, entries := range c.dirs {
+		all = append(all, entries...)
+	}
+
+	return New(all)
+}
+
+// Refresh re-lists the cached directories starting from the root
+// and updates the cache directory by directory, so readers see the
+// refreshed parts immediately. fn, if not nil, is called for every
+// found change. Listing of new directories is reported as addition
+// of all their entries and removal of a directory is reported as
+// removal of all the cached entries under it. A subdirectory which
+// the server fails to list with chubby.ServerError is considered
+// removed after its parent was listed.
+func (c *Cache) Refresh(ctx context.Context, l chubby.Lister,
+	fn func(Change)) error {
+
+	type dir struct {
+		path   string
+		parent string
+	}
+	queue := []dir{{path: c.root}}
+	visited := make(map[string]bool)
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		d := queue[0]
+		queue = queue[1:]
+		if visited[d.path] {
+			continue
+		}
+		visited[d.path] = true
+
+		entries, err := l.List(d.path)
+		if err != nil && (d.path == c.root || !chubby.IsServerError(err)) {
+			return err
+		}
+		var changes []Change
+		c.mu.Lock()
+		if err != nil {
+			changes = c.update(d.parent, without(c.dirs[d.parent], d.path))
+		} else {
+			changes = c.update(d.path, entries)
+		}
+		c.mu.Unlock()
+		if fn != nil {
+			for _, ch := range changes {
+				fn(ch)
+			}
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				queue = append(queue,
+					dir{path: path.Clean(e.Dir().Path), parent: d.path})
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.updated = stdtime.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// without returns a copy of entries without the entry with the path.
+func without(entries []chubby.Entry, pth string) []chubby.Entry {
+	var res []chubby.Entry
+	for _, e := range entries {
+		if entryPath(e) != pth {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// update replaces the cached directory listing returning changes.
+func (c *Cache) update(dir string, entries []chubby.Entry) []Change {
+	var changes []Change
+	old := make(map[string]chubby.Entry)
+	for _, e := range c.dirs[dir] {
+		old[entryPath(e)] = e
+	}
+	cur := make(map[string]bool)
+	for _, e := range entries {
+		p := entryPath(e)
+		cur[p] = true
+		o, ok := old[p]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: ChangeAdded, Entry: e})
+		case o.IsDir() != e.IsDir():
+			changes = c.remove(dir, o, changes)
+			changes = append(changes, Change{Kind: ChangeAdded, Entry: e})
+		case !sameEntry(o, e):
+			changes = append(changes,
+				Change{Kind: ChangeModified, Entry: e, Old: o})
+		}
+	}
+	for _, e := range c.dirs[dir] {
+		if !cur[entryPath(e)] {
+			changes = c.remove(dir, e, changes)
+		}
+	}
+	c.dirs[dir] = entries
+
+	return changes
+}
+
+// remove removes the entry of the parent directory listing with all
+// its cached subentries. Directories outside of the parent, e.g. links
+// to other parts of the library, are not removed recursively.
+func (c *Cache) remove(parent string, e chubby.Entry, changes []Change) []Change {
+	if e.IsDir() {
+		p := path.Clean(e.Dir().Path)
+		if strings.HasPrefix(p, parent+"/") || parent == "/" && p != "/" {
+			sub := c.dirs[p]
+			delete(c.dirs, p)
+			for _, s := range sub {
+				changes = c.remove(p, s, changes)
+			}
+		}
+	}
+
+	return append(changes, Change{Kind: ChangeRemoved, Entry: e})
+}
+
+func entryPath(e chubby.Entry) string {
+	if e.IsDir() {
+		return path.Clean(e.Dir().Path)
+	}
+
+	return path.Clean(e.Track().Path)
+}
+
+func sameEntry(a, b chubby.Entry) bool {
+	if a.IsDir() {
+		return *a.Dir() == *b.Dir()
+	}
+
+	return *a.Track() == *b.Track()
+}
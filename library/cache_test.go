@@ -0,0 +1,195 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/vchimishuk/chubby"
+)
+
+// mapLister is a chubby.Lister over the fixed directory listings.
+type mapLister map[string][]chubby.Entry
+
+func (l mapLister) List(path string) ([]chubby.Entry, error) {
+	entries, ok := l[path]
+	if !ok {
+		return nil, chubby.NewServerError("directory not found")
+	}
+
+	return entries, nil
+}
+
+func changesString(changes []Change) string {
+	var ss []string
+	for _, c := range changes {
+		ss = append(ss, c.Kind.String()+" "+entryPath(c.Entry))
+	}
+	sort.Strings(ss)
+
+	return strings.Join(ss, ", ")
+}
+
+func refresh(t *testing.T, c *Cache, l chubby.Lister) string {
+	var changes []Change
+	err := c.Refresh(context.Background(), l, func(ch Change) {
+		changes = append(changes, ch)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return changesString(changes)
+}
+
+func TestCacheRefresh(t *testing.T) {
+	l := mapLister{
+		"/": {&chubby.Dir{Path: "/a", Name: "a"},
+			&chubby.Track{Path: "/t1.flac", Title: "T1"}},
+		"/a": {&chubby.Dir{Path: "/a/b", Name: "b"},
+			&chubby.Track{Path: "/a/t2.flac", Title: "T2"}},
+		"/a/b": {&chubby.Track{Path: "/a/b/t3.flac", Title: "T3"},
+			&chubby.Dir{Path: "/", Name: "up"}},
+	}
+	c := NewCache("/")
+	if !c.Updated().IsZero() {
+		t.Fatal("cache is updated")
+	}
+
+	ch := refresh(t, c, l)
+	exp := "added /, added /a, added /a/b, added /a/b/t3.flac, " +
+		"added /a/t2.flac, added /t1.flac"
+	if ch != exp {
+		t.Fatalf("unexpected changes: %s", ch)
+	}
+	if c.Updated().IsZero() || c.Index().Len() != 3 {
+		t.Fatal("cache is not updated")
+	}
+	if ch := refresh(t, c, l); ch != "" {
+		t.Fatalf("unexpected changes: %s", ch)
+	}
+
+	l["/"] = []chubby.Entry{&chubby.Track{Path: "/t1.flac", Title: "T1 (Remastered)"},
+		&chubby.Track{Path: "/t4.flac", Title: "T4"}}
+	ch = refresh(t, c, l)
+	exp = "added /t4.flac, modified /t1.flac, removed /, removed /a, " +
+		"removed /a/b, removed /a/b/t3.flac, removed /a/t2.flac"
+	if ch != exp {
+		t.Fatalf("unexpected changes: %s", ch)
+	}
+	if _, err := c.List("/a"); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	l["/"] = []chubby.Entry{&chubby.Dir{Path: "/t1.flac", Name: "t1.flac"}}
+	l["/t1.flac"] = nil
+	ch = refresh(t, c, l)
+	if ch != "added /t1.flac, removed /t1.flac, removed /t4.flac" {
+		t.Fatalf("unexpected changes: %s", ch)
+	}
+
+	// Subdirectory removed after its parent was listed.
+	l["/"] = []chubby.Entry{&chubby.Dir{Path: "/t1.flac", Name: "t1.flac"},
+		&chubby.Dir{Path: "/c", Name: "c"}}
+	l["/c"] = []chubby.Entry{&chubby.Track{Path: "/c/t5.flac", Title: "T5"}}
+	refresh(t, c, l)
+	// "/" still lists "/c" which is gone.
+	delete(l, "/c")
+	ch = refresh(t, c, l)
+	if ch != "removed /c, removed /c/t5.flac" {
+		t.Fatalf("unexpected changes: %s", ch)
+	}
+	if es, err := c.List("/"); err != nil || len(es) != 1 {
+		t.Fatalf("unexpected entries: %v, %v", es, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Refresh(ctx, l, nil); err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	delete(l, "/")
+	if err := c.Refresh(context.Background(), l, nil); !chubby.IsServerError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCacheSave(t *testing.T) {
+	c := NewCache("/")
+	if err := c.Refresh(context.Background(), newTestPlayer(testTracks), nil); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "library.cache")
+	if err := c.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	lc, err := LoadCache(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lc.Updated().Equal(c.Updated()) || lc.Root() != "/" {
+		t.Fatalf("unexpected cache: %v, %s", lc.Updated(), lc.Root())
+	}
+
+	ix, lix := c.Index(), lc.Index()
+	if titles(ix.Tracks()) != titles(lix.Tracks()) ||
+		len(ix.Dirs()) != len(lix.Dirs()) {
+		t.Fatal("loaded cache differs")
+	}
+	for i, tr := range lix.Tracks() {
+		if *tr != *ix.Tracks()[i] {
+			t.Fatalf("%+v != %+v", tr, ix.Tracks()[i])
+		}
+	}
+	// Loaded cache can be used as a lister.
+	bix, err := Build(context.Background(), lc, "/music/Pink Floyd")
+	if err != nil || bix.Len() != 5 {
+		t.Fatalf("unexpected index: %v, %v", bix, err)
+	}
+	if ch := refresh(t, lc, newTestPlayer(testTracks)); ch != "" {
+		t.Fatalf("unexpected changes: %s", ch)
+	}
+}
+
+func TestCacheFormat(t *testing.T) {
+	if _, err := ReadCache(strings.NewReader("garbage")); err != ErrCacheFormat {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	gob.NewEncoder(&buf).Encode(cacheHeader{cacheMagic, cacheVersion + 1})
+	if _, err := ReadCache(&buf); !errors.Is(err, ErrCacheVersion) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf.Reset()
+	if err := NewCache("/").Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	buf.Truncate(buf.Len() - 1)
+	if _, err := ReadCache(&buf); !errors.Is(err, ErrCacheFormat) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+// Modification is a metadata change of the track with the same path.
+type Modification struct {
+	Old *chubby.Track
+	New *chubby.Track
+	// Fields are names of the changed fields: "artist", "album",
+	// "year", "title", "number" and "length".
+	Fields []string
+}
+
+// Move is a probable move of a track or a directory.
+type Move struct {
+	From string
+	To   string
+}
+
+// Diff is the difference between two library snapshots. Tracks which
+// disappeared from one path and appeared at another with identical
+// metadata are reported as moves instead of removal and addition.
+// Directories are reported as moved if all tracks of their subtree
+// moved under the same new directory keeping their relative paths.
+// Directories without tracks in their subtree are never reported
+// as moved. All lists are ordered by path.
+type Diff struct {
+	AddedDirs     []*chubby.Dir
+	RemovedDirs   []*chubby.Dir
+	MovedDirs     []Move
+	AddedTracks   []*chubby.Track
+	RemovedTracks []*chubby.Track
+	MovedTracks   []Move
+	Modified      []Modification
+}
+
+// metadata is the track identity used for move detection.
+type metadata struct {
+	artist string
+	album  string
+	year   int
+	title  string
+	number int
+	length time.Time
+}
+
+func trackMetadata(t *chubby.Track) metadata {
+	return metadata{t.Artist, t.Album, t.Year, t.Title, t.Number, t.Length}
+}
+
+// Compare returns the difference between old and cur snapshots.
+func Compare(old, cur *Index) *Diff {
+	d := &Diff{}
+
+	oldDirs := make(map[string]*chubby.Dir)
+	for _, dir := range old.dirs {
+		oldDirs[dir.Path] = dir
+	}
+	newDirs := make(map[string]*chubby.Dir)
+	for _, dir := range cur.dirs {
+		newDirs[dir.Path] = dir
+		if oldDirs[dir.Path] == nil {
+			d.AddedDirs = append(d.AddedDirs, dir)
+		}
+	}
+	for _, dir := range old.dirs {
+		if newDirs[dir.Path] == nil {
+			d.RemovedDirs = append(d.RemovedDirs, dir)
+		}
+	}
+
+	oldTracks := make(map[string]*chubby.Track)
+	for _, t := range old.tracks {
+		oldTracks[t.Path] = t
+	}
+	newTracks := make(map[string]*chubby.Track)
+	var added []*chubby.Track
+	for _, t := range cur.tracks {
+		newTracks[t.Path] = t
+		o := oldTracks[t.Path]
+		if o == nil {
+			added = append(added, t)
+		} else if fs := changedFields(o, t); len(fs) > 0 {
+			d.Modified = append(d.Modified, Modification{o, t, fs})
+		}
+	}
+
+	// Removed tracks are matched with added ones in path order.
+	candidates := make(map[metadata][]*chubby.Track)
+	for _, t := range added {
+		m := trackMetadata(t)
+		candidates[m] = append(candidates[m], t)
+	}
+	moved := make(map[*chubby.Track]string)
+	for _, t := range old.tracks {
+		if newTracks[t.Path] != nil {
+			continue
+		}
+		m := trackMetadata(t)
+		if cs := candidates[m]; len(cs) > 0 {
+			candidates[m] = cs[1:]
+			moved[cs[0]] = t.Path
+			d.MovedTracks = append(d.MovedTracks, Move{t.Path, cs[0].Path})
+		} else {
+			d.RemovedTracks = append(d.RemovedTracks, t)
+		}
+	}
+	for _, t := range added {
+		if _, ok := moved[t]; !ok {
+			d.AddedTracks = append(d.AddedTracks, t)
+		}
+	}
+	d.detectDirMoves(old)
+
+	return d
+}
+
+// detectDirMoves replaces removed and added directories pairs with
+// moves if all tracks of the removed directory subtree moved to the
+// same relative paths under the added one.
+func (d *Diff) detectDirMoves(old *Index) {
+	// Number of tracks in the subtree of every old directory.
+	counts := make(map[string]int)
+	for _, t := range old.tracks {
+		for _, dir := range parents(t.Path) {
+			counts[dir]++
+		}
+	}
+	// Old directory to the new one all its subtree tracks moved to.
+	targets := make(map[string]string)
+	for _, m := range d.MovedTracks {
+		for _, from := range parents(m.From) {
+			var to string
+			if rel := m.From[len(from):]; strings.HasSuffix(m.To, rel) {
+				to = strings.TrimSuffix(m.To, rel)
+			}
+			if t, ok := targets[from]; ok && t != to {
+				targets[from] = ""
+			} else {
+				targets[from] = to
+			}
+			counts[from]--
+		}
+	}
+
+	added := make(map[string]bool)
+	for _, dir := range d.AddedDirs {
+		added[dir.Path] = true
+	}
+	movedTo := make(map[string]bool)
+	var removed []*chubby.Dir
+	for _, dir := range d.RemovedDirs {
+		to := targets[dir.Path]
+		if to != "" && counts[dir.Path] == 0 && added[to] && !movedTo[to] {
+			movedTo[to] = true
+			d.MovedDirs = append(d.MovedDirs, Move{dir.Path, to})
+		} else {
+			removed = append(removed, dir)
+		}
+	}
+	d.RemovedDirs = removed
+	var adds []*chubby.Dir
+	for _, dir := range d.AddedDirs {
+		if !movedTo[dir.Path] {
+			adds = append(adds, dir)
+		}
+	}
+	d.AddedDirs = adds
+}
+
+// parents returns all parent directories of the path except the root.
+func parents(p string) []string {
+	var dirs []string
+	for dir := path.Dir(p); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+
+	return dirs
+}
+
+func changedFields(a, b *chubby.Track) []string {
+	var fs []string
+	if a.Artist != b.Artist {
+		fs = append(fs, "artist")
+	}
+	if a.Album != b.Album {
+		fs = append(fs, "album")
+	}
+	if a.Year != b.Year {
+		fs = append(fs, "year")
+	}
+	if a.Title != b.Title {
+		fs = append(fs, "title")
+	}
+	if a.Number != b.Number {
+		fs = append(fs, "number")
+	}
+	if a.Length != b.Length {
+		fs = append(fs, "length")
+	}
+
+	return fs
+}
+
+// Empty reports whether snapshots are the same.
+func (d *Diff) Empty() bool {
+	return len(d.AddedDirs) == 0 && len(d.RemovedDirs) == 0 &&
+		len(d.MovedDirs) == 0 && len(d.AddedTracks) == 0 &&
+		len(d.RemovedTracks) == 0 && len(d.MovedTracks) == 0 &&
+		len(d.Modified) == 0
+}
+
+// WriteText writes the diff in a human readable line per change
+// format: "+" marks additions, "-" removals, ">" moves and "~"
+// metadata modifications.
+func (d *Diff) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, dir := range d.RemovedDirs {
+		fmt.Fprintf(bw, "- %s/\n", dir.Path)
+	}
+	for _, dir := range d.AddedDirs {
+		fmt.Fprintf(bw, "+ %s/\n", dir.Path)
+	}
+	for _, m := range d.MovedDirs {
+		fmt.Fprintf(bw, "> %s/ -> %s/\n", m.From, m.To)
+	}
+	for _, t := range d.RemovedTracks {
+		fmt.Fprintf(bw, "- %s\n", t.Path)
+	}
+	for _, t := range d.AddedTracks {
+		fmt.Fprintf(bw, "+ %s\n", t.Path)
+	}
+	for _, m := range d.MovedTracks {
+		fmt.Fprintf(bw, "> %s -> %s\n", m.From, m.To)
+	}
+	for _, m := range d.Modified {
+		fmt.Fprintf(bw, "~ %s:", m.New.Path)
+		for i, f := range m.Fields {
+			if i > 0 {
+				bw.WriteString(",")
+			}
+			fmt.Fprintf(bw, " %s %s -> %s", f,
+				fieldString(m.Old, f), fieldString(m.New, f))
+		}
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
+
+func fieldString(t *chubby.Track, field string) string {
+	switch field {
+	case "artist":
+		return fmt.Sprintf("%q", t.Artist)
+	case "album":
+		return fmt.Sprintf("%q", t.Album)
+	case "year":
+		return fmt.Sprint(t.Year)
+	case "title":
+		return fmt.Sprintf("%q", t.Title)
+	case "number":
+		return fmt.Sprint(t.Number)
+	default:
+		return t.Length.String()
+	}
+}
+
+type jsonTrack struct {
+	Path   string    `json:"path"`
+	Artist string    `json:"artist"`
+	Album  string    `json:"album"`
+	Year   int       `json:"year"`
+	Title  string    `json:"title"`
+	Number int       `json:"number"`
+	Length time.Time `json:"length"`
+}
+
+func newJSONTrack(t *chubby.Track) jsonTrack {
+	return jsonTrack{t.Path, t.Artist, t.Album, t.Year, t.Title,
+		t.Number, t.Length}
+}
+
+type jsonMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type jsonModification struct {
+	Old    jsonTrack `json:"old"`
+	New    jsonTrack `json:"new"`
+	Fields []string  `json:"fields"`
+}
+
+type jsonDiff struct {
+	AddedDirs     []string           `json:"added_dirs"`
+	RemovedDirs   []string           `json:"removed_dirs"`
+	MovedDirs     []jsonMove         `json:"moved_dirs"`
+	AddedTracks   []jsonTrack        `json:"added_tracks"`
+	RemovedTracks []jsonTrack        `json:"removed_tracks"`
+	MovedTracks   []jsonMove         `json:"moved_tracks"`
+	Modified      []jsonModification `json:"modified"`
+}
+
+// MarshalJSON encodes the diff as an object with lists of changes.
+// Lists are never null and track lengths are in seconds.
+func (d *Diff) MarshalJSON() ([]byte, error) {
+	j := jsonDiff{
+		AddedDirs:     []string{},
+		RemovedDirs:   []string{},
+		MovedDirs:     []jsonMove{},
+		AddedTracks:   []jsonTrack{},
+		RemovedTracks: []jsonTrack{},
+		MovedTracks:   []jsonMove{},
+		Modified:      []jsonModification{},
+	}
+	for _, dir := range d.AddedDirs {
+		j.AddedDirs = append(j.AddedDirs, dir.Path)
+	}
+	for _, dir := range d.RemovedDirs {
+		j.RemovedDirs = append(j.RemovedDirs, dir.Path)
+	}
+	for _, m := range d.MovedDirs {
+		j.MovedDirs = append(j.MovedDirs, jsonMove(m))
+	}
+	for _, t := range d.AddedTracks {
+		j.AddedTracks = append(j.AddedTracks, newJSONTrack(t))
+	}
+	for _, t := range d.RemovedTracks {
+		j.RemovedTracks = append(j.RemovedTracks, newJSONTrack(t))
+	}
+	for _, m := range d.MovedTracks {
+		j.MovedTracks = append(j.MovedTracks, jsonMove(m))
+	}
+	for _, m := range d.Modified {
+		j.Modified = append(j.Modified, jsonModification{
+			newJSONTrack(m.Old), newJSONTrack(m.New), m.Fields})
+	}
+
+	return json.Marshal(&j)
+}
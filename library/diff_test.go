@@ -0,0 +1,151 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vchimishuk/chubby"
+)
+
+func TestCompare(t *testing.T) {
+	old := buildIndex(t)
+	if d := Compare(old, old); !d.Empty() {
+		t.Fatalf("unexpected diff: %+v", d)
+	}
+
+	var tracks []*chubby.Track
+	for _, tr := range testTracks {
+		c := *tr
+		switch c.Title {
+		case "Money":
+			// Removed.
+			continue
+		case "Dogs":
+			c.Year = 1978
+			c.Title = "Dogs (Live)"
+		case "Army of Me":
+			// Album directory renamed.
+			c.Path = "/music/Björk/Post/01 - Army of Me.flac"
+		case "unknown":
+			// Moved to another existing directory.
+			c.Path = "/music/Kraftwerk/unknown.mp3"
+		}
+		tracks = append(tracks, &c)
+	}
+	tracks = append(tracks, &chubby.Track{Path: "/music/new/new.flac",
+		Title: "New"})
+	cur, err := Build(context.Background(), newTestPlayer(tracks), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := Compare(old, cur)
+	var b strings.Builder
+	if err := d.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	exp := `- /music/misc/
++ /music/new/
+> /music/Björk/1995 - Post/ -> /music/Björk/Post/
+- /music/Pink Floyd/1973 - The Dark Side of the Moon/05 - Money.flac
++ /music/new/new.flac
+> /music/Björk/1995 - Post/01 - Army of Me.flac -> /music/Björk/Post/01 - Army of Me.flac
+> /music/misc/unknown.mp3 -> /music/Kraftwerk/unknown.mp3
+~ /music/Pink Floyd/1977 - Animals/02 - Dogs.flac: year 1977 -> 1978, title "Dogs" -> "Dogs (Live)"
+`
+	if b.String() != exp {
+		t.Fatalf("unexpected diff:\n%s", b.String())
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var j map[string]interface{}
+	if err := json.Unmarshal(data, &j); err != nil {
+		t.Fatal(err)
+	}
+	mod := j["modified"].([]interface{})[0].(map[string]interface{})
+	if mod["new"].(map[string]interface{})["title"] != "Dogs (Live)" ||
+		mod["old"].(map[string]interface{})["length"] != 1024.0 {
+		t.Fatalf("unexpected modification: %v", mod)
+	}
+	if len(j["moved_tracks"].([]interface{})) != 2 ||
+		len(j["added_tracks"].([]interface{})) != 1 {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+
+	data, err = json.Marshal(Compare(old, old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "null") {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
+
+func TestCompareDirMoves(t *testing.T) {
+	track := func(p, title string) *chubby.Track {
+		return &chubby.Track{Path: p, Title: title, Length: 100}
+	}
+	dir := func(p string) *chubby.Dir {
+		return &chubby.Dir{Path: p, Name: p[strings.LastIndex(p, "/")+1:]}
+	}
+	old := New([]chubby.Entry{dir("/a"), dir("/a/cd1"), dir("/a/cd2"),
+		dir("/b"), dir("/b/cd1"),
+		track("/a/cd1/1.flac", "A1"), track("/a/cd2/1.flac", "A2"),
+		track("/b/cd1/1.flac", "B1"), track("/b/cd1/2.flac", "B2")})
+	cur := New([]chubby.Entry{dir("/x"), dir("/x/cd1"), dir("/x/cd2"),
+		dir("/y"), dir("/y/cd1"), dir("/z"),
+		track("/x/cd1/1.flac", "A1"), track("/x/cd2/1.flac", "A2"),
+		// Tracks of /b are split between two directories.
+		track("/y/cd1/1.flac", "B1"), track("/z/2.flac", "B2")})
+
+	d := Compare(old, cur)
+	// Directories without tracks of their own move with the subtree.
+	exp := []Move{{"/a", "/x"}, {"/a/cd1", "/x/cd1"}, {"/a/cd2", "/x/cd2"}}
+	if !reflect.DeepEqual(exp, d.MovedDirs) {
+		t.Fatalf("%v != %v", exp, d.MovedDirs)
+	}
+	if len(d.RemovedDirs) != 2 || d.RemovedDirs[0].Path != "/b" ||
+		len(d.AddedDirs) != 3 || d.AddedDirs[0].Path != "/y" {
+
+		t.Fatalf("unexpected diff: %+v", d)
+	}
+}
+
+func TestCompareDuplicates(t *testing.T) {
+	dup := chubby.Track{Title: "Same", Length: 100}
+	a, b, c := dup, dup, dup
+	a.Path = "/a/1.flac"
+	b.Path = "/a/2.flac"
+	c.Path = "/b/1.flac"
+	old := New([]chubby.Entry{&a, &b})
+	cur := New([]chubby.Entry{&c})
+
+	d := Compare(old, cur)
+	if len(d.MovedTracks) != 1 || d.MovedTracks[0] != (Move{"/a/1.flac", "/b/1.flac"}) ||
+		len(d.RemovedTracks) != 1 || d.RemovedTracks[0].Path != "/a/2.flac" {
+		t.Fatalf("unexpected diff: %+v", d)
+	}
+}
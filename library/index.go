@@ -0,0 +1,365 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+// Package library provides client-side tools over the daemon music
+// library gathered by recursive listing: search index, fuzzy search,
+// persistent cache, snapshot diffs, metadata analysis and statistics.
+package library
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+// Match defines how query strings are compared with track fields.
+// All comparisons are case-insensitive.
+type Match int
+
+const (
+	MatchExact Match = iota
+	MatchPrefix
+	MatchSubstring
+)
+
+// Query selects tracks. Empty string fields and zero years match
+// any track, all non-empty conditions must match. Tracks with unknown
+// year never match a year range.
+type Query struct {
+	Artist string
+	Album  string
+	Title  string
+	Match  Match
+	// YearFrom and YearTo is the inclusive range of years.
+	YearFrom int
+	YearTo   int
+}
+
+// Album is a group of tracks of the same album in the same directory.
+type Album struct {
+	// Path is the directory of the album tracks.
+	Path  string
+	Title string
+	// Artist is empty if tracks have different artists.
+	Artist string
+	Year   int
+	// Tracks are ordered by Number.
+	Tracks   []*chubby.Track
+	Duration time.Time
+}
+
+// Index is an immutable in-memory index of the library.
+type Index struct {
+	dirs   []*chubby.Dir
+	tracks []*chubby.Track
+	// Tracks sorted by the corresponding lowercase field.
+	fields [3]fieldIndex
+	// Tracks sorted by year.
+	years []*chubby.Track
+	// Search documents built on the first search.
+	docsOnce sync.Once
+	docs     []document
+}
+
+type field int
+
+const (
+	fieldArtist field = iota
+	fieldAlbum
+	fieldTitle
+)
+
+type fieldIndex struct {
+	keys   []string
+	tracks []*chubby.Track
+}
+
+// Build walks the library tree rooted at root and indexes all
+// found entries.
+func Build(ctx context.Context, l chubby.Lister, root string) (*Index, error) {
+	var entries []chubby.Entry
+	root = path.Clean(root)
+	err := chubby.Walk(ctx, l, root,
+		func(pth string, e chubby.Entry, err error) error {
+			if err != nil {
+				return err
+			}
+			if pth != root {
+				entries = append(entries, e)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return New(entries), nil
+}
+
+// New indexes the given entries. Tracks are ordered by path.
+func New(entries []chubby.Entry) *Index {
+	ix := &Index{}
+	for _, e := range entries {
+		if e.IsDir() {
+			ix.dirs = append(ix.dirs, e.Dir())
+		} else {
+			ix.tracks = append(ix.tracks, e.Track())
+		}
+	}
+	sort.Slice(ix.dirs, func(i, j int) bool {
+		return ix.dirs[i].Path < ix.dirs[j].Path
+	})
+	sort.Slice(ix.tracks, func(i, j int) bool {
+		return ix.tracks[i].Path < ix.tracks[j].Path
+	})
+
+	for f := range ix.fields {
+		fi := &ix.fields[f]
+		fi.tracks = append([]*chubby.Track(nil), ix.tracks...)
+		fi.keys = make([]string, len(ix.tracks))
+		sort.SliceStable(fi.tracks, func(i, j int) bool {
+			return key(fi.tracks[i], field(f)) < key(fi.tracks[j], field(f))
+		})
+		for i, t := range fi.tracks {
+			fi.keys[i] = key(t, field(f))
+		}
+	}
+	ix.years = append([]*chubby.Track(nil), ix.tracks...)
+	sort.SliceStable(ix.years, func(i, j int) bool {
+		return ix.years[i].Year < ix.years[j].Year
+	})
+
+	return ix
+}
+
+func (ix *Index) Len() int {
+	return len(ix.tracks)
+}
+
+// Tracks returns all tracks ordered by path.
+func (ix *Index) Tracks() []*chubby.Track {
+	return append([]*chubby.Track(nil), ix.tracks...)
+}
+
+// Dirs returns all directories ordered by path.
+func (ix *Index) Dirs() []*chubby.Dir {
+	return append([]*chubby.Dir(nil), ix.dirs...)
+}
+
+// Artists returns distinct non-empty artist names sorted
+// case-insensitively. The first met spelling of every name is used.
+func (ix *Index) Artists() []string {
+	var artists []string
+	fi := &ix.fields[fieldArtist]
+	for i, k := range fi.keys {
+		if k != "" && (i == 0 || fi.keys[i-1] != k) {
+			artists = append(artists, fi.tracks[i].Artist)
+		}
+	}
+
+	return artists
+}
+
+// Duration returns the total duration of all tracks.
+func (ix *Index) Duration() time.Time {
+	return Duration(ix.tracks)
+}
+
+// Find returns tracks matching the query ordered by artist, year,
+// album, number and path.
+func (ix *Index) Find(q Query) []*chubby.Track {
+	cands := ix.candidates(q)
+	var res []*chubby.Track
+	for _, t := range cands {
+		if q.matches(t) {
+			res = append(res, t)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return less(res[i], res[j])
+	})
+
+	return res
+}
+
+// Albums returns albums which have at least one track matching
+// the query. Albums contain all their tracks and are ordered by
+// artist, year and title.
+func (ix *Index) Albums(q Query) []*Album {
+	type albumKey struct {
+		dir   string
+		title string
+	}
+	matched := make(map[albumKey]bool)
+	for _, t := range ix.Find(q) {
+		matched[albumKey{path.Dir(t.Path), t.Album}] = true
+	}
+
+	groups := make(map[albumKey]*Album)
+	var albums []*Album
+	for _, t := range ix.tracks {
+		k := albumKey{path.Dir(t.Path), t.Album}
+		if !matched[k] {
+			continue
+		}
+		a, ok := groups[k]
+		if !ok {
+			a = &Album{Path: k.dir, Title: t.Album, Artist: t.Artist,
+				Year: t.Year}
+			groups[k] = a
+			albums = append(albums, a)
+		}
+		if a.Artist != t.Artist {
+			a.Artist = ""
+		}
+		if a.Year == 0 {
+			a.Year = t.Year
+		}
+		a.Tracks = append(a.Tracks, t)
+		a.Duration += t.Length
+	}
+
+	for _, a := range albums {
+		sort.SliceStable(a.Tracks, func(i, j int) bool {
+			return a.Tracks[i].Number < a.Tracks[j].Number
+		})
+	}
+	sort.SliceStable(albums, func(i, j int) bool {
+		a, b := albums[i], albums[j]
+		if x, y := strings.ToLower(a.Artist), strings.ToLower(b.Artist); x != y {
+			return x < y
+		}
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+
+	return albums
+}
+
+// Duration returns the total duration of the tracks.
+func Duration(tracks []*chubby.Track) time.Time {
+	var d time.Time
+	for _, t := range tracks {
+		d += t.Length
+	}
+
+	return d
+}
+
+// candidates returns a superset of tracks matching the query
+// using the most suitable index.
+func (ix *Index) candidates(q Query) []*chubby.Track {
+	for f, s := range []string{q.Artist, q.Album, q.Title} {
+		if s == "" || q.Match == MatchSubstring {
+			continue
+		}
+		fi := &ix.fields[f]
+		s = strings.ToLower(s)
+		i := sort.SearchStrings(fi.keys, s)
+		j := i
+		for j < len(fi.keys) && (fi.keys[j] == s ||
+			q.Match == MatchPrefix && strings.HasPrefix(fi.keys[j], s)) {
+			j++
+		}
+		return fi.tracks[i:j]
+	}
+
+	if q.YearFrom != 0 || q.YearTo != 0 {
+		i := sort.Search(len(ix.years), func(i int) bool {
+			return ix.years[i].Year >= q.YearFrom && ix.years[i].Year != 0
+		})
+		j := len(ix.years)
+		if q.YearTo != 0 {
+			j = sort.Search(len(ix.years), func(i int) bool {
+				return ix.years[i].Year > q.YearTo
+			})
+		}
+		if i > j {
+			i = j
+		}
+		return ix.years[i:j]
+	}
+
+	return ix.tracks
+}
+
+func (q *Query) matches(t *chubby.Track) bool {
+	for f, s := range []string{q.Artist, q.Album, q.Title} {
+		if s != "" && !q.Match.match(key(t, field(f)), strings.ToLower(s)) {
+			return false
+		}
+	}
+	if (q.YearFrom != 0 || q.YearTo != 0) && t.Year == 0 {
+		return false
+	}
+	if q.YearFrom != 0 && t.Year < q.YearFrom {
+		return false
+	}
+	if q.YearTo != 0 && t.Year > q.YearTo {
+		return false
+	}
+
+	return true
+}
+
+func (m Match) match(v, s string) bool {
+	switch m {
+	case MatchExact:
+		return v == s
+	case MatchPrefix:
+		return strings.HasPrefix(v, s)
+	case MatchSubstring:
+		return strings.Contains(v, s)
+	default:
+		panic("unsupported Match")
+	}
+}
+
+func key(t *chubby.Track, f field) string {
+	switch f {
+	case fieldArtist:
+		return strings.ToLower(t.Artist)
+	case fieldAlbum:
+		return strings.ToLower(t.Album)
+	default:
+		return strings.ToLower(t.Title)
+	}
+}
+
+func less(a, b *chubby.Track) bool {
+	if x, y := strings.ToLower(a.Artist), strings.ToLower(b.Artist); x != y {
+		return x < y
+	}
+	if a.Year != b.Year {
+		return a.Year < b.Year
+	}
+	if x, y := strings.ToLower(a.Album), strings.ToLower(b.Album); x != y {
+		return x < y
+	}
+	if a.Number != b.Number {
+		return a.Number < b.Number
+	}
+
+	return a.Path < b.Path
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/chubbytest"
+)
+
+var testTracks = []*chubby.Track{
+	{Path: "/music/Pink Floyd/1977 - Animals/02 - Dogs.flac",
+		Artist: "Pink Floyd", Album: "Animals", Year: 1977,
+		Title: "Dogs", Number: 2, Length: 1024},
+	{Path: "/music/Pink Floyd/1977 - Animals/01 - Pigs on the Wing 1.flac",
+		Artist: "Pink Floyd", Album: "Animals", Year: 1977,
+		Title: "Pigs on the Wing 1", Number: 1, Length: 85},
+	{Path: "/music/Pink Floyd/1977 - Animals/03 - Pigs.flac",
+		Artist: "Pink Floyd", Album: "Animals", Year: 1977,
+		Title: "Pigs (Three Different Ones)", Number: 3, Length: 681},
+	{Path: "/music/Pink Floyd/1973 - The Dark Side of the Moon/01 - Speak to Me.flac",
+		Artist: "Pink Floyd", Album: "The Dark Side of the Moon", Year: 1973,
+		Title: "Speak to Me", Number: 1, Length: 68},
+	{Path: "/music/Pink Floyd/1973 - The Dark Side of the Moon/05 - Money.flac",
+		Artist: "Pink Floyd", Album: "The Dark Side of the Moon", Year: 1973,
+		Title: "Money", Number: 5, Length: 382},
+	{Path: "/music/Kraftwerk/1977 - Trans-Europe Express/01 - Europe Endless.flac",
+		Artist: "Kraftwerk", Album: "Trans-Europe Express", Year: 1977,
+		Title: "Europe Endless", Number: 1, Length: 574},
+	{Path: "/music/Kraftwerk/1974 - Autobahn/01 - Autobahn.flac",
+		Artist: "Kraftwerk", Album: "Autobahn", Year: 1974,
+		Title: "Autobahn", Number: 1, Length: 1368},
+	{Path: "/music/Björk/1995 - Post/01 - Army of Me.flac",
+		Artist: "Björk", Album: "Post", Year: 1995,
+		Title: "Army of Me", Number: 1, Length: 234},
+	{Path: "/music/misc/unknown.mp3", Title: "unknown", Length: 100},
+}
+
+// newTestPlayer returns a player with a copy of the given tracks.
+func newTestPlayer(tracks []*chubby.Track) *chubbytest.Player {
+	p := chubbytest.NewPlayer()
+	for _, t := range tracks {
+		c := *t
+		p.AddTrack(&c)
+	}
+
+	return p
+}
+
+func buildIndex(t *testing.T) *Index {
+	ix, err := Build(context.Background(), newTestPlayer(testTracks), "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ix
+}
+
+func titles(tracks []*chubby.Track) string {
+	var ts []string
+	for _, t := range tracks {
+		ts = append(ts, t.Title)
+	}
+
+	return strings.Join(ts, ", ")
+}
+
+func TestBuild(t *testing.T) {
+	ix := buildIndex(t)
+
+	if ix.Len() != len(testTracks) {
+		t.Fatalf("unexpected number of tracks: %d", ix.Len())
+	}
+	if len(ix.Dirs()) != 10 {
+		t.Fatalf("unexpected number of dirs: %d", len(ix.Dirs()))
+	}
+	if d := ix.Duration(); d != 4516 {
+		t.Fatalf("unexpected duration: %d", d)
+	}
+	if a := strings.Join(ix.Artists(), ", "); a != "Björk, Kraftwerk, Pink Floyd" {
+		t.Fatalf("unexpected artists: %s", a)
+	}
+
+	ix, err := Build(context.Background(), newTestPlayer(testTracks),
+		"/music/Kraftwerk/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ix.Len() != 2 || len(ix.Dirs()) != 2 {
+		t.Fatalf("unexpected index: %v, %v", ix.Tracks(), ix.Dirs())
+	}
+
+	_, err = Build(context.Background(), newTestPlayer(testTracks), "/missing")
+	if !chubby.IsServerError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	ix := buildIndex(t)
+
+	tests := []struct {
+		q   Query
+		res string
+	}{
+		{Query{Artist: "pink floyd", Album: "animals"},
+			"Pigs on the Wing 1, Dogs, Pigs (Three Different Ones)"},
+		{Query{Artist: "pink"}, ""},
+		{Query{Artist: "pink", Match: MatchPrefix, YearFrom: 1975},
+			"Pigs on the Wing 1, Dogs, Pigs (Three Different Ones)"},
+		{Query{Title: "pigs", Match: MatchPrefix},
+			"Pigs on the Wing 1, Pigs (Three Different Ones)"},
+		{Query{Title: "E", Match: MatchSubstring, YearTo: 1974},
+			"Speak to Me, Money"},
+		{Query{Title: "N", Match: MatchSubstring, YearTo: 1974},
+			"Autobahn, Money"},
+		{Query{YearFrom: 1977, YearTo: 1977},
+			"Europe Endless, Pigs on the Wing 1, Dogs, Pigs (Three Different Ones)"},
+		{Query{YearFrom: 1990}, "Army of Me"},
+		{Query{YearFrom: 1980, YearTo: 1970}, ""},
+		{Query{Album: "post", Title: "army of me"}, "Army of Me"},
+		{Query{}, "unknown, Army of Me, Autobahn, Europe Endless, " +
+			"Speak to Me, Money, Pigs on the Wing 1, Dogs, " +
+			"Pigs (Three Different Ones)"},
+	}
+	for _, test := range tests {
+		if res := titles(ix.Find(test.q)); res != test.res {
+			t.Fatalf("%+v: %s != %s", test.q, res, test.res)
+		}
+	}
+}
+
+func TestAlbums(t *testing.T) {
+	ix := buildIndex(t)
+
+	albums := ix.Albums(Query{Title: "pigs", Match: MatchSubstring})
+	if len(albums) != 1 {
+		t.Fatalf("unexpected albums: %v", albums)
+	}
+	a := albums[0]
+	if a.Title != "Animals" || a.Artist != "Pink Floyd" || a.Year != 1977 ||
+		a.Path != "/music/Pink Floyd/1977 - Animals" || a.Duration != 1790 {
+		t.Fatalf("unexpected album: %+v", a)
+	}
+	if ts := titles(a.Tracks); ts != "Pigs on the Wing 1, Dogs, Pigs (Three Different Ones)" {
+		t.Fatalf("unexpected tracks: %s", ts)
+	}
+
+	var names []string
+	for _, a := range ix.Albums(Query{YearFrom: 1970, YearTo: 1979}) {
+		names = append(names, a.Title)
+	}
+	exp := "Autobahn, Trans-Europe Express, The Dark Side of the Moon, Animals"
+	if strings.Join(names, ", ") != exp {
+		t.Fatalf("unexpected albums: %v", names)
+	}
+}
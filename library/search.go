@@ -0,0 +1,244 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"path"
+	"sort"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/internal/fold"
+)
+
+// minTokenScore is the minimum score every query word must have
+// for an entry to be found.
+const minTokenScore = 0.5
+
+// Result is a fuzzy search result.
+type Result struct {
+	Entry chubby.Entry
+	// Path of the entry which can be passed to Play.
+	Path string
+	// Score is in the (0, 1] range, higher is better.
+	Score float64
+}
+
+// document is a searchable entry with its folded words.
+type document struct {
+	entry chubby.Entry
+	path  string
+	words []string
+}
+
+// Search finds tracks and directories fuzzily matching the query.
+// Every query word must match some word of the entry metadata:
+// words are compared case and diacritic insensitively tolerating
+// typos, prefixes and omitted letters, so "pnk floid anml" finds
+// the "Pink Floyd/Animals" album. Tracks are matched by artist, album
+// and title, directories by their and their parent names. Results are
+// ordered by score, directories go before tracks with the same score.
+// At most limit results are returned if limit is positive.
+func (ix *Index) Search(query string, limit int) []Result {
+	qws := fold.Fields(query)
+	if len(qws) == 0 {
+		return nil
+	}
+
+	ix.docsOnce.Do(ix.buildDocs)
+	var res []Result
+	for i := range ix.docs {
+		d := &ix.docs[i]
+		if s := score(qws, d.words); s > 0 {
+			res = append(res, Result{Entry: d.entry, Path: d.path, Score: s})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Entry.IsDir() != b.Entry.IsDir() {
+			return a.Entry.IsDir()
+		}
+		return len(a.Path) < len(b.Path)
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res
+}
+
+func (ix *Index) buildDocs() {
+	ix.docs = make([]document, 0, len(ix.dirs)+len(ix.tracks))
+	for _, d := range ix.dirs {
+		parent := path.Base(path.Dir(d.Path))
+		ix.docs = append(ix.docs, document{
+			entry: d,
+			path:  d.Path,
+			words: fold.Fields(parent + " " + path.Base(d.Path)),
+		})
+	}
+	for _, t := range ix.tracks {
+		ix.docs = append(ix.docs, document{
+			entry: t,
+			path:  t.Path,
+			words: fold.Fields(t.Artist + " " + t.Album + " " + t.Title),
+		})
+	}
+}
+
+// score returns the average of the best scores of all query words
+// weighted by the share of the document words matched, or 0 if some
+// query word does not match.
+func score(qws, dws []string) float64 {
+	if len(dws) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	matched := make([]bool, len(dws))
+	for _, q := range qws {
+		best := 0.0
+		bi := -1
+		for i, d := range dws {
+			if s := wordScore(q, d); s > best {
+				best = s
+				bi = i
+			}
+		}
+		if best < minTokenScore {
+			return 0
+		}
+		total += best
+		matched[bi] = true
+	}
+	n := 0
+	for _, m := range matched {
+		if m {
+			n++
+		}
+	}
+
+	return total / float64(len(qws)) *
+		(0.8 + 0.2*float64(n)/float64(len(dws)))
+}
+
+// wordScore returns similarity of the query word q to the document
+// word d in the [0, 1] range.
+func wordScore(q, d string) float64 {
+	if q == d {
+		return 1
+	}
+	qr := []rune(q)
+	dr := []rune(d)
+	if len(qr) <= len(dr) && string(dr[:len(qr)]) == q {
+		return 0.9
+	}
+
+	best := 0.0
+	// Typos in the whole word or in the word prefix.
+	tol := tolerance(len(qr))
+	for _, n := range []int{len(dr), len(qr)} {
+		if n > len(dr) || tol == 0 {
+			continue
+		}
+		dist := distance(qr, dr[:n])
+		if dist <= tol {
+			s := 0.85 - 0.15*float64(dist)
+			if n < len(dr) {
+				s -= 0.05
+			}
+			if s > best {
+				best = s
+			}
+		}
+	}
+	// Omitted letters, like in "anml" for "animals".
+	if len(qr) >= 2 && qr[0] == dr[0] && subsequence(qr, dr) {
+		s := 0.5 + 0.3*float64(len(qr))/float64(len(dr))
+		if s > best {
+			best = s
+		}
+	}
+
+	return best
+}
+
+// tolerance returns the number of typos allowed in a word
+// of the given length.
+func tolerance(n int) int {
+	switch {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func subsequence(q, d []rune) bool {
+	i := 0
+	for _, r := range d {
+		if i < len(q) && q[i] == r {
+			i++
+		}
+	}
+
+	return i == len(q)
+}
+
+// distance returns the optimal string alignment distance, which is
+// the Levenshtein distance with transpositions of adjacent letters.
+func distance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] &&
+				prev2[j-2]+1 < cur[j] {
+				cur[j] = prev2[j-2] + 1
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+
+	return a
+}
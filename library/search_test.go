@@ -0,0 +1,114 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/chubbytest"
+)
+
+func TestSearch(t *testing.T) {
+	ix := buildIndex(t)
+
+	tests := []struct {
+		q    string
+		path string
+	}{
+		{"pnk floid anml", "/music/Pink Floyd/1977 - Animals"},
+		{"pink floyd dark side", "/music/Pink Floyd/1973 - The Dark Side of the Moon"},
+		{"bjork", "/music/Björk"},
+		{"bjork army", "/music/Björk/1995 - Post/01 - Army of Me.flac"},
+		{"BJÖRK POST", "/music/Björk/1995 - Post"},
+		{"kraftwrek autobhan", "/music/Kraftwerk/1974 - Autobahn"},
+		{"mony", "/music/Pink Floyd/1973 - The Dark Side of the Moon/05 - Money.flac"},
+		{"trans europe", "/music/Kraftwerk/1977 - Trans-Europe Express"},
+	}
+	for _, test := range tests {
+		res := ix.Search(test.q, 3)
+		if len(res) == 0 || res[0].Path != test.path {
+			t.Fatalf("%s: unexpected results: %v", test.q, res)
+		}
+		for i := 1; i < len(res); i++ {
+			if res[i].Score > res[i-1].Score {
+				t.Fatalf("%s: results are not ordered: %v", test.q, res)
+			}
+		}
+	}
+
+	for _, q := range []string{"", " - ", "zeppelin", "pink zeppelin", "xz"} {
+		if res := ix.Search(q, 0); len(res) != 0 {
+			t.Fatalf("%s: unexpected results: %v", q, res)
+		}
+	}
+	if res := ix.Search("pink", 2); len(res) != 2 {
+		t.Fatalf("unexpected results: %v", res)
+	}
+}
+
+func TestSearchPlay(t *testing.T) {
+	p := newTestPlayer(testTracks)
+	ix, err := Build(context.Background(), p, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := chubbytest.NewRecorder(p)
+
+	res := ix.Search("animals dogs", 1)
+	if len(res) != 1 {
+		t.Fatalf("unexpected results: %v", res)
+	}
+	if err := rec.Play(res[0].Path); err != nil {
+		t.Fatal(err)
+	}
+	s, err := rec.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.State != chubby.StatePlaying || s.Track.Title != "Dogs" {
+		t.Fatalf("unexpected status: %+v", s)
+	}
+}
+
+func TestWordScore(t *testing.T) {
+	tests := []struct {
+		q, d string
+		ok   bool
+	}{
+		{"pink", "pink", true},
+		{"pnk", "pink", true},
+		{"floid", "floyd", true},
+		{"anml", "animals", true},
+		{"teh", "the", true},
+		{"ab", "ba", false},
+		{"dogs", "pigs", false},
+		{"moon", "money", false},
+	}
+	for _, test := range tests {
+		s := wordScore(test.q, test.d)
+		if (s >= minTokenScore) != test.ok {
+			t.Fatalf("%s, %s: unexpected score %f", test.q, test.d, s)
+		}
+	}
+	if wordScore("pink", "pink") <= wordScore("pin", "pink") ||
+		wordScore("pin", "pink") <= wordScore("pnk", "pink") {
+		t.Fatal("exact match must score higher")
+	}
+}
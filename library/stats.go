@@ -0,0 +1,287 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+// ArtistStats are statistics of a single artist. Artist names are
+// compared the same way as by Analyze, the first met spelling is used.
+type ArtistStats struct {
+	Artist   string    `json:"artist"`
+	Albums   int       `json:"albums"`
+	Tracks   int       `json:"tracks"`
+	Duration time.Time `json:"duration"`
+}
+
+// DecadeStats are statistics of tracks released in a decade.
+type DecadeStats struct {
+	// Decade is the first year of the decade, or 0 for tracks
+	// with unknown year.
+	Decade   int       `json:"decade"`
+	Albums   int       `json:"albums"`
+	Tracks   int       `json:"tracks"`
+	Duration time.Time `json:"duration"`
+}
+
+// AlbumStats are statistics of a single album, albums are grouped
+// the same way as by Index.Albums.
+type AlbumStats struct {
+	Path     string    `json:"path"`
+	Artist   string    `json:"artist"`
+	Title    string    `json:"title"`
+	Year     int       `json:"year"`
+	Tracks   int       `json:"tracks"`
+	Duration time.Time `json:"duration"`
+}
+
+// Stats are library statistics. Tracks without album are not
+// counted as albums and tracks without artist as artists. All
+// durations are in seconds in JSON.
+type Stats struct {
+	Tracks   int       `json:"tracks"`
+	Albums   int       `json:"albums"`
+	Artists  int       `json:"artists"`
+	Duration time.Time `json:"duration"`
+	// ByArtist is ordered by duration, longest first.
+	ByArtist []ArtistStats `json:"by_artist"`
+	// ByDecade is ordered by decade, unknown one goes last.
+	ByDecade []DecadeStats `json:"by_decade"`
+	// LongestAlbums is ordered by duration, longest first.
+	LongestAlbums []AlbumStats `json:"longest_albums"`
+}
+
+// statsCollector accumulates statistics track by track, so
+// the library can be processed in a single pass while walking.
+type statsCollector struct {
+	stats   Stats
+	artists map[string]*ArtistStats
+	decades map[int]*DecadeStats
+	albums  map[albumID]*AlbumStats
+	// Albums every artist and decade has tracks on.
+	artistAlbums map[string]map[albumID]bool
+	decadeAlbums map[int]map[albumID]bool
+}
+
+type albumID struct {
+	dir   string
+	title string
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		artists:      make(map[string]*ArtistStats),
+		decades:      make(map[int]*DecadeStats),
+		albums:       make(map[albumID]*AlbumStats),
+		artistAlbums: make(map[string]map[albumID]bool),
+		decadeAlbums: make(map[int]map[albumID]bool),
+	}
+}
+
+func (c *statsCollector) add(t *chubby.Track) {
+	c.stats.Tracks++
+	c.stats.Duration += t.Length
+
+	id := albumID{path.Dir(t.Path), t.Album}
+	if t.Album != "" {
+		a, ok := c.albums[id]
+		if !ok {
+			a = &AlbumStats{Path: id.dir, Artist: t.Artist, Title: t.Album,
+				Year: t.Year}
+			c.albums[id] = a
+		}
+		if a.Artist != t.Artist {
+			a.Artist = ""
+		}
+		if a.Year == 0 {
+			a.Year = t.Year
+		}
+		a.Tracks++
+		a.Duration += t.Length
+	}
+
+	if t.Artist != "" {
+		k := artistKey(t.Artist)
+		a, ok := c.artists[k]
+		if !ok {
+			a = &ArtistStats{Artist: t.Artist}
+			c.artists[k] = a
+			c.artistAlbums[k] = make(map[albumID]bool)
+		}
+		a.Tracks++
+		a.Duration += t.Length
+		if t.Album != "" && !c.artistAlbums[k][id] {
+			c.artistAlbums[k][id] = true
+			a.Albums++
+		}
+	}
+
+	dec := t.Year / 10 * 10
+	d, ok := c.decades[dec]
+	if !ok {
+		d = &DecadeStats{Decade: dec}
+		c.decades[dec] = d
+		c.decadeAlbums[dec] = make(map[albumID]bool)
+	}
+	d.Tracks++
+	d.Duration += t.Length
+	if t.Album != "" && !c.decadeAlbums[dec][id] {
+		c.decadeAlbums[dec][id] = true
+		d.Albums++
+	}
+}
+
+// result returns the statistics with at most top longest albums,
+// all albums are returned if top is not positive.
+func (c *statsCollector) result(top int) *Stats {
+	s := c.stats
+	s.Albums = len(c.albums)
+	s.Artists = len(c.artists)
+
+	s.ByArtist = []ArtistStats{}
+	for _, a := range c.artists {
+		s.ByArtist = append(s.ByArtist, *a)
+	}
+	sort.Slice(s.ByArtist, func(i, j int) bool {
+		a, b := s.ByArtist[i], s.ByArtist[j]
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+		return a.Artist < b.Artist
+	})
+
+	s.ByDecade = []DecadeStats{}
+	for _, d := range c.decades {
+		s.ByDecade = append(s.ByDecade, *d)
+	}
+	sort.Slice(s.ByDecade, func(i, j int) bool {
+		a, b := s.ByDecade[i].Decade, s.ByDecade[j].Decade
+		if a == 0 || b == 0 {
+			return b == 0 && a != 0
+		}
+		return a < b
+	})
+
+	s.LongestAlbums = []AlbumStats{}
+	for _, a := range c.albums {
+		s.LongestAlbums = append(s.LongestAlbums, *a)
+	}
+	sort.Slice(s.LongestAlbums, func(i, j int) bool {
+		a, b := s.LongestAlbums[i], s.LongestAlbums[j]
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+		return a.Path < b.Path
+	})
+	if top > 0 && len(s.LongestAlbums) > top {
+		s.LongestAlbums = s.LongestAlbums[:top]
+	}
+
+	return &s
+}
+
+// ComputeStats returns statistics of the tracks with at most top
+// longest albums. If top is zero or negative all albums are listed.
+func ComputeStats(tracks []*chubby.Track, top int) *Stats {
+	c := newStatsCollector()
+	for _, t := range tracks {
+		c.add(t)
+	}
+
+	return c.result(top)
+}
+
+// CollectStats walks the library tree rooted at root and returns
+// statistics of all found tracks with at most top longest albums.
+// If top is zero or negative all albums are listed.
+// Tracks are not kept in memory while walking.
+func CollectStats(ctx context.Context, l chubby.Lister, root string,
+	top int) (*Stats, error) {
+
+	c := newStatsCollector()
+	err := chubby.Walk(ctx, l, root,
+		func(path string, e chubby.Entry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !e.IsDir() {
+				c.add(e.Track())
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.result(top), nil
+}
+
+// WriteText writes the statistics as text tables.
+func (s *Stats) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Tracks:\t%d\n", s.Tracks)
+	fmt.Fprintf(tw, "Albums:\t%d\n", s.Albums)
+	fmt.Fprintf(tw, "Artists:\t%d\n", s.Artists)
+	fmt.Fprintf(tw, "Playtime:\t%s\n", s.Duration)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(s.ByArtist) > 0 {
+		fmt.Fprintf(tw, "\nARTIST\tALBUMS\tTRACKS\tPLAYTIME\n")
+		for _, a := range s.ByArtist {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", a.Artist, a.Albums,
+				a.Tracks, a.Duration)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(s.ByDecade) > 0 {
+		fmt.Fprintf(tw, "\nDECADE\tALBUMS\tTRACKS\tPLAYTIME\n")
+		for _, d := range s.ByDecade {
+			dec := "unknown"
+			if d.Decade != 0 {
+				dec = fmt.Sprintf("%ds", d.Decade)
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", dec, d.Albums,
+				d.Tracks, d.Duration)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(s.LongestAlbums) > 0 {
+		fmt.Fprintf(tw, "\nALBUM\tARTIST\tYEAR\tTRACKS\tPLAYTIME\n")
+		for _, a := range s.LongestAlbums {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", a.Title, a.Artist,
+				a.Year, a.Tracks, a.Duration)
+		}
+	}
+
+	return tw.Flush()
+}
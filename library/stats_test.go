@@ -0,0 +1,106 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package library
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	s, err := CollectStats(context.Background(), newTestPlayer(testTracks), "/", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs := ComputeStats(testTracks, 2); !reflect.DeepEqual(s, cs) {
+		t.Fatalf("%+v != %+v", s, cs)
+	}
+
+	var b strings.Builder
+	if err := s.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	exp := `Tracks:    9
+Albums:    5
+Artists:   3
+Playtime:  1:15:16
+
+ARTIST      ALBUMS  TRACKS  PLAYTIME
+Pink Floyd  2       5       37:20
+Kraftwerk   2       2       32:22
+Björk       1       1       3:54
+
+DECADE   ALBUMS  TRACKS  PLAYTIME
+1970s    4       7       1:09:42
+1990s    1       1       3:54
+unknown  0       1       1:40
+
+ALBUM     ARTIST      YEAR  TRACKS  PLAYTIME
+Animals   Pink Floyd  1977  3       29:50
+Autobahn  Kraftwerk   1974  1       22:48
+`
+	if b.String() != exp {
+		t.Fatalf("unexpected stats:\n%s", b.String())
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var j struct {
+		Duration int
+		ByArtist []struct {
+			Artist   string
+			Duration int
+		} `json:"by_artist"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		t.Fatal(err)
+	}
+	if j.Duration != 4516 || len(j.ByArtist) != 3 ||
+		j.ByArtist[0].Artist != "Pink Floyd" || j.ByArtist[0].Duration != 2240 {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+
+	data, err = json.Marshal(ComputeStats(nil, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "null") {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
+
+func TestStatsTop(t *testing.T) {
+	for _, top := range []int{-1, 0, 5, 10} {
+		s := ComputeStats(testTracks, top)
+		if len(s.LongestAlbums) != 5 {
+			t.Fatalf("top %d: unexpected albums: %+v", top, s.LongestAlbums)
+		}
+	}
+	s, err := CollectStats(context.Background(), newTestPlayer(testTracks), "/", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.LongestAlbums) != 5 {
+		t.Fatalf("unexpected albums: %+v", s.LongestAlbums)
+	}
+}
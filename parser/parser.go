@@ -1,4 +1,4 @@
-// Copyright 2016 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+// Copyright 2016-2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
 //
 // This file is part of chubby.
 //
@@ -19,6 +19,7 @@ package parser
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"unicode"
@@ -28,10 +29,26 @@ import (
 type impl struct {
 	s   string
 	pos int
+	// Return numbers as int64 thousandths instead of int.
+	millis bool
 }
 
+// Parse parses the line into a map of string, int and bool values.
+// Fractional numbers are truncated to int, use ParseMillis to get
+// their exact values.
 func Parse(s string) (map[string]interface{}, error) {
-	p := &impl{s: s, pos: 0}
+	return parse(&impl{s: s})
+}
+
+// ParseMillis is like Parse but numbers are returned as int64 values
+// in thousandths rounded half away from zero, e.g. 12.345 is 12345
+// and 12 is 12000. It is meant for time fields in seconds which can
+// be fractional.
+func ParseMillis(s string) (map[string]interface{}, error) {
+	return parse(&impl{s: s, millis: true})
+}
+
+func parse(p *impl) (map[string]interface{}, error) {
 	m := make(map[string]interface{})
 
 	for !p.eol() {
@@ -141,13 +158,19 @@ func (p *impl) string() (string, error) {
 	return string(buf), nil
 }
 
-func (p *impl) number() (int, error) {
+// number parses integer or fractional number returning it as int
+// or, in millis mode, as int64 thousandths.
+func (p *impl) number() (interface{}, error) {
 	k := 0
+	frac := false
 
-	for !p.eol() {
+	for p.pos+k < len(p.s) {
 		r, n := utf8.DecodeRuneInString(p.s[p.pos+k:])
 		if unicode.IsNumber(r) {
 			k += n
+		} else if r == '.' && !frac && k > 0 {
+			frac = true
+			k += n
 		} else {
 			break
 		}
@@ -156,13 +179,25 @@ func (p *impl) number() (int, error) {
 		return 0, newError(p.pos, "number expected")
 	}
 	s := p.s[p.pos : p.pos+k]
-	n, err := strconv.Atoi(s)
+	if strings.HasSuffix(s, ".") {
+		return 0, newError(p.pos+k, "number expected")
+	}
+	var v interface{}
+	var err error
+	if p.millis {
+		var f float64
+		f, err = strconv.ParseFloat(s, 64)
+		v = int64(math.Round(f * 1000))
+	} else {
+		s, _, _ = strings.Cut(s, ".")
+		v, err = strconv.Atoi(s)
+	}
 	if err != nil {
 		return 0, newError(p.pos, "invalid number")
 	}
 	p.pos += k
 
-	return n, nil
+	return v, nil
 }
 
 func (p *impl) boolean() (bool, error) {
@@ -1,4 +1,4 @@
-// Copyright 2016 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+// Copyright 2016-2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
 //
 // This file is part of chubby.
 //
@@ -35,6 +35,44 @@ func TestNumber(t *testing.T) {
 	}
 }
 
+func TestFraction(t *testing.T) {
+	s := `foo: 0.5, bar: 12.345, baz: 10, qux: 1.0005, s: "1.5"`
+	err := testMap(s, map[string]interface{}{
+		"foo": 0,
+		"bar": 12,
+		"baz": 10,
+		"qux": 1,
+		"s":   "1.5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ParseMillis(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"foo": int64(500),
+		"bar": int64(12345),
+		"baz": int64(10000),
+		"qux": int64(1001),
+		"s":   "1.5",
+	}
+	if !reflect.DeepEqual(exp, m) {
+		t.Fatalf("%+v != %+v", exp, m)
+	}
+
+	for _, s := range []string{`foo: 1.`, `foo: 1.2.3`, `foo: .5`} {
+		if _, err := Parse(s); err == nil {
+			t.Fatalf("%s: error expected", s)
+		}
+		if _, err := ParseMillis(s); err == nil {
+			t.Fatalf("%s: error expected", s)
+		}
+	}
+}
+
 func TestString(t *testing.T) {
 	err := testMap(`aaa: "foo", bbb: "foo bar baz", ccc: "foo\"bar'baz", ddd: "абвгд"`,
 		map[string]interface{}{
@@ -0,0 +1,121 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package playlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vchimishuk/chubby"
+)
+
+const (
+	m3uHeader   = "#EXTM3U"
+	m3uInfo     = "#EXTINF:"
+	m3uPlaylist = "#PLAYLIST:"
+)
+
+// encodeM3U writes the extended M3U playlist. The playlist name is
+// written with the #PLAYLIST directive. Latin-1 playlist fails if
+// some string is not representable in Latin-1.
+func encodeM3U(w io.Writer, p *Playlist, latin1 bool) error {
+	var b strings.Builder
+	b.WriteString(m3uHeader + "\n")
+	if p.Name != "" {
+		b.WriteString(m3uPlaylist + p.Name + "\n")
+	}
+	for _, t := range p.Tracks {
+		fmt.Fprintf(&b, "%s%d,%s\n", m3uInfo, seconds(t), displayName(t))
+		b.WriteString(t.Path + "\n")
+	}
+
+	s := b.String()
+	if !latin1 {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+	data := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return fmt.Errorf("%q is not representable in %s, use %s",
+				r, M3U, M3U8)
+		}
+		data = append(data, byte(r))
+	}
+	_, err := w.Write(data)
+
+	return err
+}
+
+// decodeM3U reads M3U and M3U8 playlists. Lines which are not valid
+// UTF-8 are considered to be Latin-1. Plain M3U without directives
+// is accepted too.
+func decodeM3U(r io.Reader) (*Playlist, error) {
+	p := &Playlist{}
+	var info *chubby.Track
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(latin1ToUTF8(sc.Text()))
+		line = strings.TrimPrefix(line, "\ufeff")
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, m3uInfo):
+			info = &chubby.Track{}
+			attrs := strings.TrimPrefix(line, m3uInfo)
+			secs, name, _ := strings.Cut(attrs, ",")
+			// Length can be followed by key="value" attributes.
+			secs, _, _ = strings.Cut(secs, " ")
+			if s, err := strconv.ParseFloat(secs, 64); err == nil {
+				setSeconds(info, int(s))
+			}
+			parseDisplayName(info, strings.TrimSpace(name))
+		case strings.HasPrefix(line, m3uPlaylist):
+			p.Name = strings.TrimSpace(strings.TrimPrefix(line, m3uPlaylist))
+		case strings.HasPrefix(line, "#"):
+		default:
+			t := info
+			if t == nil {
+				t = &chubby.Track{}
+			}
+			t.Path = entryPath(line)
+			p.Tracks = append(p.Tracks, t)
+			info = nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func latin1ToUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	rs := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		rs[i] = rune(s[i])
+	}
+
+	return string(rs)
+}
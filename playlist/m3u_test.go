@@ -0,0 +1,65 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package playlist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestM3U(t *testing.T) {
+	p := &Playlist{Name: "Björk", Tracks: testTracks[:1]}
+	var b bytes.Buffer
+	if err := Encode(&b, M3U, p); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b.Bytes(), []byte("Bj\xf6rk - Army of Me\n")) {
+		t.Fatalf("playlist is not in Latin-1: %q", b.String())
+	}
+	d, err := Decode(&b, M3U)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "Björk" || len(d.Tracks) != 1 {
+		t.Fatalf("unexpected playlist: %+v", d)
+	}
+	tr := d.Tracks[0]
+	if tr.Path != testTracks[0].Path || tr.Artist != "Björk" ||
+		tr.Title != "Army of Me" || tr.Length != 234 ||
+		tr.LengthMillis != 234000 {
+		t.Fatalf("unexpected track: %+v", tr)
+	}
+
+	p.Name = "Мьюзик"
+	if err := Encode(&b, M3U, p); err == nil {
+		t.Fatal("non Latin-1 playlist is encoded")
+	}
+
+	data := "\ufeff/a/1.mp3\r\n# comment\r\n\r\n" +
+		"#EXTINF:12.5 tvg-id=\"x\",Title Only\r\nhttp://radio/stream\r\n"
+	d, err = Decode(strings.NewReader(data), M3U8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "" || paths(d.Tracks) != "/a/1.mp3, http://radio/stream" ||
+		d.Tracks[1].Title != "Title Only" || d.Tracks[1].Artist != "" ||
+		d.Tracks[1].Length != 12 {
+		t.Fatalf("unexpected playlist: %+v", d)
+	}
+}
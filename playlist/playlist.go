@@ -0,0 +1,288 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+// Package playlist reads and writes playlists in M3U, M3U8, PLS and
+// XSPF formats and moves them between files and the daemon.
+//
+// The protocol has no commands to get tracks of a playlist or to add
+// tracks to it. So Export writes tracks supplied by the caller and
+// Import only creates an empty playlist and returns the resolved
+// tracks, it is up to the caller to fill the playlist.
+package playlist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+// Format is a playlist file format.
+type Format int
+
+const (
+	// M3U is the extended M3U in Latin-1 encoding.
+	M3U Format = iota
+	// M3U8 is the extended M3U in UTF-8 encoding.
+	M3U8
+	PLS
+	XSPF
+)
+
+var (
+	ErrFormat     = errors.New("unknown playlist format")
+	ErrNoPlaylist = errors.New("no playlist")
+	ErrNoName     = errors.New("playlist has no name")
+)
+
+var formats = []struct {
+	format Format
+	name   string
+	ext    string
+}{
+	{M3U, "M3U", ".m3u"},
+	{M3U8, "M3U8", ".m3u8"},
+	{PLS, "PLS", ".pls"},
+	{XSPF, "XSPF", ".xspf"},
+}
+
+func (f Format) String() string {
+	for _, fm := range formats {
+		if fm.format == f {
+			return fm.name
+		}
+	}
+
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// FormatByName returns the format of the file by its extension.
+func FormatByName(name string) (Format, error) {
+	ext := strings.ToLower(path.Ext(name))
+	for _, fm := range formats {
+		if fm.ext == ext {
+			return fm.format, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrFormat, name)
+}
+
+// Playlist is a playlist file contents. Tracks have only paths and
+// metadata stored in the file: M3U and PLS store length and
+// "Artist - Title" display name, XSPF stores all fields except year.
+// Name is empty if the format or the file does not store it.
+type Playlist struct {
+	Name   string
+	Tracks []*chubby.Track
+}
+
+// Encode writes the playlist in the format f.
+func Encode(w io.Writer, f Format, p *Playlist) error {
+	switch f {
+	case M3U:
+		return encodeM3U(w, p, true)
+	case M3U8:
+		return encodeM3U(w, p, false)
+	case PLS:
+		return encodePLS(w, p)
+	case XSPF:
+		return encodeXSPF(w, p)
+	default:
+		return fmt.Errorf("%w: %s", ErrFormat, f)
+	}
+}
+
+// Decode reads the playlist in the format f.
+func Decode(r io.Reader, f Format) (*Playlist, error) {
+	switch f {
+	case M3U, M3U8:
+		return decodeM3U(r)
+	case PLS:
+		return decodePLS(r)
+	case XSPF:
+		return decodeXSPF(r)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrFormat, f)
+	}
+}
+
+// Export writes the playlist pl consisting of the tracks in
+// the format f. The daemon does not report playlist tracks, so
+// they have to be supplied by the caller. To export the current
+// playlist pass Status.Playlist, which is nil if nothing is played
+// and ErrNoPlaylist is returned then.
+func Export(w io.Writer, f Format, pl *chubby.Playlist,
+	tracks []*chubby.Track) error {
+
+	if pl == nil {
+		return ErrNoPlaylist
+	}
+
+	return Encode(w, f, &Playlist{Name: pl.Name, Tracks: tracks})
+}
+
+// Library is the part of chubby.Client used by Import.
+type Library interface {
+	chubby.Lister
+	CreatePlaylist(name string) error
+}
+
+// Imported is the result of Import.
+type Imported struct {
+	Name string
+	// Tracks are library tracks found for playlist entries,
+	// in the playlist order.
+	Tracks []*chubby.Track
+	// Missing are entries not found in the library.
+	Missing []string
+}
+
+// Import reads the playlist in the format f, resolves its entries
+// against the library and creates the playlist in the daemon. Entries
+// are looked up by listing their directories, relative paths are
+// relative to the library root. The playlist is named name, or
+// the name stored in the file if name is empty.
+//
+// The protocol can not add tracks to a playlist, so the created
+// playlist is empty and the resolved tracks are returned instead.
+func Import(l Library, r io.Reader, f Format, name string) (*Imported, error) {
+	p, err := Decode(r, f)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = p.Name
+	}
+	if name == "" {
+		return nil, ErrNoName
+	}
+
+	im := &Imported{Name: name, Tracks: []*chubby.Track{},
+		Missing: []string{}}
+	dirs := make(map[string]map[string]*chubby.Track)
+	for _, t := range p.Tracks {
+		pth := path.Join("/", t.Path)
+		dir := path.Dir(pth)
+		tracks, ok := dirs[dir]
+		if !ok {
+			tracks, err = listTracks(l, dir)
+			if err != nil {
+				return nil, err
+			}
+			dirs[dir] = tracks
+		}
+		if lt, ok := tracks[pth]; ok {
+			im.Tracks = append(im.Tracks, lt)
+		} else {
+			im.Missing = append(im.Missing, t.Path)
+		}
+	}
+
+	if err := l.CreatePlaylist(name); err != nil {
+		return nil, err
+	}
+
+	return im, nil
+}
+
+// listTracks returns tracks of the directory by their clean paths.
+// Directory which does not exist has no tracks.
+func listTracks(l chubby.Lister, dir string) (map[string]*chubby.Track, error) {
+	tracks := make(map[string]*chubby.Track)
+	entries, err := l.List(dir)
+	if chubby.IsServerError(err) {
+		return tracks, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if t, ok := chubby.AsTrack(e); ok {
+			tracks[path.Clean(t.Path)] = t
+		}
+	}
+
+	return tracks, nil
+}
+
+// displayName returns "Artist - Title" name of the track used
+// by M3U and PLS.
+func displayName(t *chubby.Track) string {
+	switch {
+	case t.Artist != "" && t.Title != "":
+		return t.Artist + " - " + t.Title
+	case t.Title != "":
+		return t.Title
+	default:
+		return path.Base(t.Path)
+	}
+}
+
+// parseDisplayName fills track artist and title from
+// the "Artist - Title" name.
+func parseDisplayName(t *chubby.Track, name string) {
+	if i := strings.Index(name, " - "); i >= 0 {
+		t.Artist, t.Title = name[:i], name[i+3:]
+	} else {
+		t.Title = name
+	}
+}
+
+// seconds returns the track length in seconds, or -1 if it is
+// unknown, as M3U and PLS store it.
+func seconds(t *chubby.Track) int {
+	if t.Length <= 0 {
+		return -1
+	}
+
+	return int(t.Length)
+}
+
+// setSeconds sets the track length from seconds stored by M3U or PLS.
+func setSeconds(t *chubby.Track, s int) {
+	if s > 0 {
+		t.Length = time.New(s)
+		t.LengthMillis = t.Length.Millis()
+	}
+}
+
+// entryPath returns the library path of the playlist entry location,
+// which is either a path or a file URL. Other URLs are returned
+// unchanged.
+func entryPath(loc string) string {
+	if !strings.HasPrefix(strings.ToLower(loc), "file:") {
+		return loc
+	}
+	u, err := url.Parse(loc)
+	if err != nil || u.Path == "" {
+		return loc
+	}
+
+	return u.Path
+}
+
+// fileURL returns the file URL of the library path.
+func fileURL(pth string) string {
+	u := url.URL{Scheme: "file", Path: pth}
+
+	return u.String()
+}
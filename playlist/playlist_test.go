@@ -0,0 +1,132 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package playlist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/chubbytest"
+)
+
+var testTracks = []*chubby.Track{
+	{Path: "/music/Björk/Post/01 - Army of Me.flac", Artist: "Björk",
+		Album: "Post", Year: 1995, Title: "Army of Me", Number: 1,
+		Length: 234, LengthMillis: 234120},
+	{Path: "/music/Kraftwerk/Autobahn/01 - Autobahn.flac", Artist: "Kraftwerk",
+		Album: "Autobahn", Year: 1974, Title: "Autobahn", Number: 1,
+		Length: 1368, LengthMillis: 1368000},
+	{Path: "/music/misc/unknown.mp3"},
+}
+
+func paths(tracks []*chubby.Track) string {
+	var ps []string
+	for _, t := range tracks {
+		ps = append(ps, t.Path)
+	}
+
+	return strings.Join(ps, ", ")
+}
+
+func TestFormatByName(t *testing.T) {
+	tests := map[string]Format{
+		"a.m3u":      M3U,
+		"/b/c.M3U8":  M3U8,
+		"d.pls":      PLS,
+		"e.tar.xspf": XSPF,
+	}
+	for name, exp := range tests {
+		if f, err := FormatByName(name); err != nil || f != exp {
+			t.Errorf("%s: unexpected format: %v, %v", name, f, err)
+		}
+	}
+	if _, err := FormatByName("f.txt"); !errors.Is(err, ErrFormat) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var b strings.Builder
+	if err := Export(&b, M3U8, nil, testTracks); err != ErrNoPlaylist {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pl := &chubby.Playlist{Name: "mix", Length: len(testTracks)}
+	if err := Export(&b, M3U8, pl, testTracks); err != nil {
+		t.Fatal(err)
+	}
+	exp := `#EXTM3U
+#PLAYLIST:mix
+#EXTINF:234,Björk - Army of Me
+/music/Björk/Post/01 - Army of Me.flac
+#EXTINF:1368,Kraftwerk - Autobahn
+/music/Kraftwerk/Autobahn/01 - Autobahn.flac
+#EXTINF:-1,unknown.mp3
+/music/misc/unknown.mp3
+`
+	if b.String() != exp {
+		t.Fatalf("unexpected playlist:\n%s", b.String())
+	}
+}
+
+func TestImport(t *testing.T) {
+	p := chubbytest.NewPlayer()
+	for _, tr := range testTracks {
+		c := *tr
+		p.AddTrack(&c)
+	}
+
+	data := `[playlist]
+File1=music/Kraftwerk/Autobahn/01 - Autobahn.flac
+File2=/music/Björk/Post/02 - Possibly Maybe.flac
+File3=file:///music/Bj%C3%B6rk/Post/01%20-%20Army%20of%20Me.flac
+File4=/nowhere/track.flac
+`
+	if _, err := Import(p, strings.NewReader(data), PLS, ""); err != ErrNoName {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	im, err := Import(p, strings.NewReader(data), PLS, "imported")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if im.Name != "imported" ||
+		paths(im.Tracks) != paths(testTracks[1:2])+", "+paths(testTracks[:1]) ||
+		strings.Join(im.Missing, ", ") != "/music/Björk/Post/02 - Possibly Maybe.flac, "+
+			"/nowhere/track.flac" {
+		t.Fatalf("unexpected import: %+v", im)
+	}
+	if im.Tracks[0].Album != "Autobahn" {
+		t.Fatalf("track is not resolved: %+v", im.Tracks[0])
+	}
+	pls, err := p.Playlists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pls) != 1 || pls[0].Name != "imported" {
+		t.Fatalf("unexpected playlists: %+v", pls)
+	}
+	if _, err := Import(p, strings.NewReader(data), PLS, "imported"); !chubby.IsServerError(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p.Kill()
+	if _, err := Import(p, strings.NewReader(data), PLS, "other"); err != chubby.ErrNotConnected {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
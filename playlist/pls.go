@@ -0,0 +1,122 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package playlist
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vchimishuk/chubby"
+)
+
+const plsSection = "[playlist]"
+
+// encodePLS writes the PLS version 2 playlist. PLS does not store
+// the playlist name.
+func encodePLS(w io.Writer, p *Playlist) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(plsSection + "\n")
+	for i, t := range p.Tracks {
+		n := i + 1
+		fmt.Fprintf(bw, "File%d=%s\n", n, t.Path)
+		fmt.Fprintf(bw, "Title%d=%s\n", n, displayName(t))
+		fmt.Fprintf(bw, "Length%d=%d\n", n, seconds(t))
+	}
+	fmt.Fprintf(bw, "NumberOfEntries=%d\n", len(p.Tracks))
+	bw.WriteString("Version=2\n")
+
+	return bw.Flush()
+}
+
+// decodePLS reads the PLS playlist. Entries are ordered by their
+// numbers, which do not have to be contiguous.
+func decodePLS(r io.Reader) (*Playlist, error) {
+	tracks := make(map[int]*chubby.Track)
+	found, section := false, false
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(latin1ToUTF8(sc.Text()))
+		line = strings.TrimPrefix(line, "\ufeff")
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			section = strings.EqualFold(line, plsSection)
+			found = found || section
+			continue
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !section || !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		var field string
+		for _, f := range []string{"file", "title", "length"} {
+			if strings.HasPrefix(key, f) {
+				field = f
+				break
+			}
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(key, field))
+		if field == "" || err != nil {
+			continue
+		}
+		t, ok := tracks[n]
+		if !ok {
+			t = &chubby.Track{}
+			tracks[n] = t
+		}
+		switch field {
+		case "file":
+			t.Path = entryPath(val)
+		case "title":
+			parseDisplayName(t, val)
+		case "length":
+			if s, err := strconv.Atoi(val); err == nil {
+				setSeconds(t, s)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("invalid PLS playlist: no [playlist] section")
+	}
+
+	var nums []int
+	for n, t := range tracks {
+		if t.Path != "" {
+			nums = append(nums, n)
+		}
+	}
+	sort.Ints(nums)
+	p := &Playlist{}
+	for _, n := range nums {
+		p.Tracks = append(p.Tracks, tracks[n])
+	}
+
+	return p, nil
+}
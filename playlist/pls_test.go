@@ -0,0 +1,74 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package playlist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPLS(t *testing.T) {
+	var b strings.Builder
+	if err := Encode(&b, PLS, &Playlist{Name: "mix", Tracks: testTracks}); err != nil {
+		t.Fatal(err)
+	}
+	exp := `[playlist]
+File1=/music/Björk/Post/01 - Army of Me.flac
+Title1=Björk - Army of Me
+Length1=234
+File2=/music/Kraftwerk/Autobahn/01 - Autobahn.flac
+Title2=Kraftwerk - Autobahn
+Length2=1368
+File3=/music/misc/unknown.mp3
+Title3=unknown.mp3
+Length3=-1
+NumberOfEntries=3
+Version=2
+`
+	if b.String() != exp {
+		t.Fatalf("unexpected playlist:\n%s", b.String())
+	}
+	d, err := Decode(strings.NewReader(b.String()), PLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths(d.Tracks) != paths(testTracks) || d.Tracks[1].Artist != "Kraftwerk" ||
+		d.Tracks[1].Length != 1368 || d.Tracks[2].Length != 0 {
+		t.Fatalf("unexpected playlist: %+v", d)
+	}
+
+	data := `[other]
+File1=/x.mp3
+[Playlist]
+file10=/c.mp3
+Title2=B
+FILE2=/b.mp3
+File1=/a.mp3
+Title5=No file
+`
+	d, err = Decode(strings.NewReader(data), PLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths(d.Tracks) != "/a.mp3, /b.mp3, /c.mp3" || d.Tracks[1].Title != "B" {
+		t.Fatalf("unexpected playlist: %+v", d)
+	}
+	if _, err := Decode(strings.NewReader("File1=/a.mp3\n"), PLS); err == nil {
+		t.Fatal("invalid playlist is decoded")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package playlist
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/vchimishuk/chubby"
+	"github.com/vchimishuk/chubby/time"
+)
+
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+// xspfPlaylist is the XSPF document, namespace is not required
+// while decoding.
+type xspfPlaylist struct {
+	XMLName xml.Name `xml:"playlist"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Title   string   `xml:"title,omitempty"`
+	// TrackList is always written, even if empty, as required.
+	TrackList struct {
+		Tracks []xspfTrack `xml:"track"`
+	} `xml:"trackList"`
+}
+
+type xspfTrack struct {
+	// Location can be repeated, the first one is used.
+	Location []string `xml:"location"`
+	Creator  string   `xml:"creator,omitempty"`
+	Album    string   `xml:"album,omitempty"`
+	Title    string   `xml:"title,omitempty"`
+	TrackNum int      `xml:"trackNum,omitempty"`
+	// Duration is in milliseconds.
+	Duration int64 `xml:"duration,omitempty"`
+}
+
+// encodeXSPF writes the XSPF version 1 playlist. Track paths are
+// written as file URLs.
+func encodeXSPF(w io.Writer, p *Playlist) error {
+	x := xspfPlaylist{Xmlns: xspfNamespace, Version: "1", Title: p.Name}
+	for _, t := range p.Tracks {
+		ms := t.LengthMillis
+		if ms == 0 {
+			ms = t.Length.Millis()
+		}
+		x.TrackList.Tracks = append(x.TrackList.Tracks, xspfTrack{
+			Location: []string{fileURL(t.Path)},
+			Creator:  t.Artist,
+			Album:    t.Album,
+			Title:    t.Title,
+			TrackNum: t.Number,
+			Duration: int64(ms),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&x); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+// decodeXSPF reads the XSPF playlist. Tracks without location
+// are skipped.
+func decodeXSPF(r io.Reader) (*Playlist, error) {
+	var x xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, err
+	}
+
+	p := &Playlist{Name: x.Title}
+	for _, xt := range x.TrackList.Tracks {
+		if len(xt.Location) == 0 {
+			continue
+		}
+		t := &chubby.Track{
+			Path:         entryPath(xt.Location[0]),
+			Artist:       xt.Creator,
+			Album:        xt.Album,
+			Title:        xt.Title,
+			Number:       xt.TrackNum,
+			LengthMillis: time.Millis(xt.Duration),
+		}
+		t.Length = t.LengthMillis.Time()
+		p.Tracks = append(p.Tracks, t)
+	}
+
+	return p, nil
+}
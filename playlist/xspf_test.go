@@ -0,0 +1,93 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package playlist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXSPF(t *testing.T) {
+	var b strings.Builder
+	if err := Encode(&b, XSPF, &Playlist{Name: "mix", Tracks: testTracks[:2]}); err != nil {
+		t.Fatal(err)
+	}
+	exp := `<?xml version="1.0" encoding="UTF-8"?>
+<playlist xmlns="http://xspf.org/ns/0/" version="1">
+  <title>mix</title>
+  <trackList>
+    <track>
+      <location>file:///music/Bj%C3%B6rk/Post/01%20-%20Army%20of%20Me.flac</location>
+      <creator>Björk</creator>
+      <album>Post</album>
+      <title>Army of Me</title>
+      <trackNum>1</trackNum>
+      <duration>234120</duration>
+    </track>
+    <track>
+      <location>file:///music/Kraftwerk/Autobahn/01%20-%20Autobahn.flac</location>
+      <creator>Kraftwerk</creator>
+      <album>Autobahn</album>
+      <title>Autobahn</title>
+      <trackNum>1</trackNum>
+      <duration>1368000</duration>
+    </track>
+  </trackList>
+</playlist>
+`
+	if b.String() != exp {
+		t.Fatalf("unexpected playlist:\n%s", b.String())
+	}
+	d, err := Decode(strings.NewReader(b.String()), XSPF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "mix" || len(d.Tracks) != 2 {
+		t.Fatalf("unexpected playlist: %+v", d)
+	}
+	for i, tr := range d.Tracks {
+		exp := *testTracks[i]
+		exp.Year = 0
+		if *tr != exp {
+			t.Fatalf("%+v != %+v", tr, exp)
+		}
+	}
+
+	data := `<playlist version="1"><trackList>
+<track><title>No location</title></track>
+<track><location>/a.mp3</location><location>/b.mp3</location></track>
+</trackList></playlist>`
+	d, err = Decode(strings.NewReader(data), XSPF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "" || paths(d.Tracks) != "/a.mp3" {
+		t.Fatalf("unexpected playlist: %+v", d)
+	}
+	if _, err := Decode(strings.NewReader("<playlist>"), XSPF); err == nil {
+		t.Fatal("invalid playlist is decoded")
+	}
+
+	b.Reset()
+	if err := Encode(&b, XSPF, &Playlist{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "<trackList></trackList>") {
+		t.Fatalf("unexpected playlist:\n%s", b.String())
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"context"
+	"errors"
+	"sync"
+	stdtime "time"
+
+	"github.com/vchimishuk/chubby/time"
+)
+
+var (
+	ErrQueueFull   = errors.New("queue is full")
+	ErrExpired     = errors.New("command expired")
+	ErrQueueClosed = errors.New("queue closed")
+	ErrRelative    = errors.New("relative change can not be queued")
+)
+
+type queued struct {
+	name string
+	args []interface{}
+	key  string
+	// value of buffered absolute Volume and Seek commands which
+	// relative changes are added to.
+	value    int
+	deadline stdtime.Time
+	timer    *stdtime.Timer
+	futures  []*Future[struct{}]
+}
+
+// stop stops the TTL timer and reports if the command has not
+// expired yet.
+func (q *queued) stop() bool {
+	return q.timer == nil || q.timer.Stop()
+}
+
+func (q *queued) resolve(err error) {
+	for _, f := range q.futures {
+		f.resolve(struct{}{}, err)
+	}
+}
+
+// Queue sends commands which are safe to be delayed. Commands issued
+// while the client is disconnected are buffered and sent in order
+// as soon as the connection is established again. Buffered absolute
+// Volume and Seek commands override previous ones. Relative Volume
+// and Seek commands are not safe to be replayed later. They are sent
+// right away while the client is connected, unless an absolute command
+// of the same kind is still buffered. Otherwise they are added to
+// the buffered absolute command and fail with ErrRelative if there
+// is none. Commands which stay buffered for longer than TTL fail
+// with ErrExpired.
+type Queue struct {
+	c        *Chubby
+	limit    int
+	ttl      stdtime.Duration
+	hook     *hook
+	mu       sync.Mutex
+	items    []*queued
+	flushing bool
+	closed   bool
+}
+
+// NewQueue returns a new queue over the client which buffers at most
+// limit commands for at most ttl each. Zero or negative limit and ttl
+// mean no limit.
+func NewQueue(c *Chubby, limit int, ttl stdtime.Duration) *Queue {
+	q := &Queue{c: c, limit: limit, ttl: ttl}
+	q.hook = c.addConnectHook(q.flush)
+
+	return q
+}
+
+// Len returns the number of buffered commands.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.items)
+}
+
+// Close fails all buffered commands with ErrQueueClosed.
+func (q *Queue) Close() {
+	q.c.removeConnectHook(q.hook)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	for _, it := range q.items {
+		it.stop()
+		it.resolve(ErrQueueClosed)
+	}
+	q.items = nil
+}
+
+func (q *Queue) CreatePlaylist(name string) *Future[struct{}] {
+	return q.submit(cmdCreatePlaylist, name)
+}
+
+func (q *Queue) DeletePlaylist(name string) *Future[struct{}] {
+	return q.submit(cmdDeletePlaylist, name)
+}
+
+func (q *Queue) Play(pth string) *Future[struct{}] {
+	return q.submit(cmdPlay, pth)
+}
+
+func (q *Queue) RenamePlaylist(from, to string) *Future[struct{}] {
+	return q.submit(cmdRenamePlaylist, from, to)
+}
+
+func (q *Queue) Seek(time time.Time, mode SeekMode) *Future[struct{}] {
+	t, rel := seekArgs(time, mode)
+	if rel {
+		return q.adjust(cmdSeek, t, seekAbs, t, rel)
+	}
+
+	return q.enqueue(seekAbs(t))
+}
+
+func (q *Queue) Stop() *Future[struct{}] {
+	return q.submit(cmdStop)
+}
+
+func (q *Queue) Volume(vol int, mode VolumeMode) *Future[struct{}] {
+	if mode == VolumeModeRel {
+		return q.adjust(cmdVolume, vol, volumeAbs, vol, mode)
+	}
+
+	return q.enqueue(volumeAbs(vol))
+}
+
+// seekAbs returns absolute seek command to position t, which is
+// limited to be non-negative.
+func seekAbs(t int) *queued {
+	if t < 0 {
+		t = 0
+	}
+
+	return &queued{name: cmdSeek, key: cmdSeek, value: t,
+		args: []interface{}{t, false}}
+}
+
+// volumeAbs returns absolute volume command with volume limited
+// to [0, 100] range.
+func volumeAbs(vol int) *queued {
+	if vol < 0 {
+		vol = 0
+	} else if vol > 100 {
+		vol = 100
+	}
+
+	return &queued{name: cmdVolume, key: cmdVolume, value: vol,
+		args: []interface{}{vol, VolumeModeAbs}}
+}
+
+// adjust adds delta to the value of the buffered absolute command
+// with the given key, abs returns the command for the new value.
+// If there is no such command and the client is connected the relative
+// command key with args is sent as is, as Chubby.Do does.
+func (q *Queue) adjust(key string, delta int, abs func(v int) *queued,
+	args ...interface{}) *Future[struct{}] {
+
+	f := newFuture[struct{}]()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		f.resolve(struct{}{}, ErrQueueClosed)
+		return f
+	}
+	for _, it := range q.items {
+		if it.key == key {
+			a := abs(it.value + delta)
+			it.value, it.args = a.value, a.args
+			it.futures = append(it.futures, f)
+			return f
+		}
+	}
+	sess := q.c.sess.Load()
+	if sess == nil || sess.closed() {
+		f.resolve(struct{}{}, ErrRelative)
+		return f
+	}
+	// Send now to keep the order of changes, but do not wait
+	// for the reply.
+	cl := newCall(key, args...)
+	if err := sess.send(cl); err != nil {
+		f.resolve(struct{}{}, err)
+		return f
+	}
+	go func() {
+		<-cl.done
+		f.resolve(struct{}{}, cl.err)
+	}()
+
+	return f
+}
+
+// submit buffers the command.
+func (q *Queue) submit(name string, args ...interface{}) *Future[struct{}] {
+	return q.enqueue(&queued{name: name, args: args})
+}
+
+// enqueue buffers the command and starts its TTL timer. Commands
+// with non-empty key replace all buffered commands with the same key.
+func (q *Queue) enqueue(it *queued) *Future[struct{}] {
+	f := newFuture[struct{}]()
+	it.deadline = stdtime.Now().Add(q.ttl)
+	it.futures = []*Future[struct{}]{f}
+	key := it.key
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		f.resolve(struct{}{}, ErrQueueClosed)
+		return f
+	}
+	if key != "" {
+		items := q.items[:0]
+		for _, i := range q.items {
+			if i.key == key {
+				i.stop()
+				it.futures = append(it.futures, i.futures...)
+			} else {
+				items = append(items, i)
+			}
+		}
+		q.items = items
+	}
+	if q.limit > 0 && len(q.items) >= q.limit {
+		q.mu.Unlock()
+		it.resolve(ErrQueueFull)
+		return f
+	}
+	q.items = append(q.items, it)
+	q.arm(it)
+	q.mu.Unlock()
+
+	if q.c.Connected() {
+		go q.flush()
+	}
+
+	return f
+}
+
+// arm starts TTL timer of the buffered command.
+func (q *Queue) arm(it *queued) {
+	if q.ttl <= 0 {
+		return
+	}
+	it.timer = stdtime.AfterFunc(stdtime.Until(it.deadline), func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		for i, j := range q.items {
+			if j == it {
+				q.items = append(q.items[:i], q.items[i+1:]...)
+				it.resolve(ErrExpired)
+				return
+			}
+		}
+	})
+}
+
+// flush sends buffered commands one by one until the queue is empty
+// or the connection is lost.
+func (q *Queue) flush() {
+	q.mu.Lock()
+	if q.flushing {
+		q.mu.Unlock()
+		return
+	}
+	q.flushing = true
+	q.mu.Unlock()
+
+	for {
+		q.mu.Lock()
+		if len(q.items) == 0 || !q.c.Connected() {
+			q.flushing = false
+			q.mu.Unlock()
+			return
+		}
+		it := q.items[0]
+		q.items = q.items[1:]
+		if !it.stop() {
+			// Expired but the timer function has not removed it yet.
+			q.mu.Unlock()
+			it.resolve(ErrExpired)
+			continue
+		}
+		q.mu.Unlock()
+
+		_, err := q.c.Do(context.Background(), it.name, it.args...)
+		if err == ErrNotConnected {
+			// Command was not sent, keep it for the next connection.
+			q.mu.Lock()
+			q.items = append([]*queued{it}, q.items...)
+			q.arm(it)
+			q.flushing = false
+			q.mu.Unlock()
+			return
+		}
+		it.resolve(err)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vchimishuk/chubby/textconn"
+)
+
+// recorder is a server handler which answers every command
+// with an empty response and records it.
+type recorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *recorder) handle(conn *textconn.TextConn) {
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		r.lines = append(r.lines, line)
+		r.mu.Unlock()
+		writeResp(conn, "OK")
+	}
+}
+
+func (r *recorder) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.lines...)
+}
+
+func TestQueue(t *testing.T) {
+	checkLeaks(t)
+	rec := &recorder{}
+	srv := newTestServer(t, rec.handle)
+	c := &Chubby{}
+	q := NewQueue(c, 10, time.Minute)
+	defer q.Close()
+
+	v1 := q.Volume(10, VolumeModeAbs)
+	p := q.Play("/music")
+	v2 := q.Volume(5, VolumeModeRel)
+	v3 := q.Volume(20, VolumeModeAbs)
+	if q.Len() != 2 {
+		t.Fatalf("%d != 2", q.Len())
+	}
+
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for _, f := range []*Future[struct{}]{v1, p, v2, v3} {
+		if err := f.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exp := []string{`play "/music"`, `volume 20 false`}
+	if !reflect.DeepEqual(exp, rec.recorded()) {
+		t.Fatalf("%v != %v", exp, rec.recorded())
+	}
+
+	// Connected queue sends immediately.
+	if err := q.Stop().Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueueRelative(t *testing.T) {
+	checkLeaks(t)
+	rec := &recorder{}
+	srv := newTestServer(t, rec.handle)
+	c := &Chubby{}
+	q := NewQueue(c, 10, time.Minute)
+	defer q.Close()
+
+	if err := q.Volume(5, VolumeModeRel).Err(); err != ErrRelative {
+		t.Fatalf("%v != %v", err, ErrRelative)
+	}
+	if err := q.Seek(5, SeekModeForward).Err(); err != ErrRelative {
+		t.Fatalf("%v != %v", err, ErrRelative)
+	}
+	fs := []*Future[struct{}]{
+		q.Volume(90, VolumeModeAbs),
+		q.Volume(30, VolumeModeRel),
+		q.Volume(-15, VolumeModeRel),
+		q.Seek(10, SeekModeAbs),
+		q.Seek(4, SeekModeForward),
+		q.Seek(20, SeekModeBackward),
+		q.Seek(7, SeekModeForward),
+	}
+	if q.Len() != 2 {
+		t.Fatalf("%d != 2", q.Len())
+	}
+
+	if err := c.Connect("127.0.0.1", srv.port()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for _, f := range fs {
+		if err := f.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Connected queue sends relative changes as is.
+	for _, f := range []*Future[struct{}]{
+		q.Volume(5, VolumeModeRel),
+		q.Seek(3, SeekModeBackward),
+	} {
+		if err := f.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Changes are limited to the valid range when folded.
+	exp := []string{`volume 85 false`, `seek 7 false`,
+		`volume 5 true`, `seek -3 true`}
+	if !reflect.DeepEqual(exp, rec.recorded()) {
+		t.Fatalf("%v != %v", exp, rec.recorded())
+	}
+}
+
+func TestQueueLimits(t *testing.T) {
+	c := &Chubby{}
+	q := NewQueue(c, 2, 50*time.Millisecond)
+
+	f1 := q.Play("/a")
+	q.Play("/b")
+	if err := q.Play("/c").Err(); err != ErrQueueFull {
+		t.Fatalf("%v != %v", err, ErrQueueFull)
+	}
+	if err := f1.Err(); err != ErrExpired {
+		t.Fatalf("%v != %v", err, ErrExpired)
+	}
+
+	f := q.Stop()
+	q.Close()
+	if err := f.Err(); err != ErrQueueClosed {
+		t.Fatalf("%v != %v", err, ErrQueueClosed)
+	}
+
+	// Zero limit and TTL disable the limits.
+	q = NewQueue(c, 0, 0)
+	defer q.Close()
+	fs := []*Future[struct{}]{q.Play("/a"), q.Play("/b"), q.Play("/c")}
+	time.Sleep(100 * time.Millisecond)
+	if q.Len() != 3 {
+		t.Fatalf("%d != 3", q.Len())
+	}
+	for _, f := range fs {
+		select {
+		case <-f.Done():
+			t.Fatalf("unexpected error: %v", f.Err())
+		default:
+		}
+	}
+}
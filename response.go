@@ -0,0 +1,102 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/vchimishuk/chubby/parser"
+)
+
+// Response is a successful response to a command. Lines are parsed
+// into maps on the first request only.
+type Response struct {
+	Command string
+	Lines   []string
+	once    sync.Once
+	maps    []map[string]interface{}
+	err     error
+}
+
+// Maps returns all response lines parsed with parser.Parse.
+func (r *Response) Maps() ([]map[string]interface{}, error) {
+	r.once.Do(func() {
+		r.maps = make([]map[string]interface{}, len(r.Lines))
+		for i, line := range r.Lines {
+			m, err := parser.Parse(line)
+			if err != nil {
+				r.maps = nil
+				r.err = newProtocolError(r.Command, line, true, err)
+				return
+			}
+			r.maps[i] = m
+		}
+	})
+
+	return r.maps, r.err
+}
+
+// Map returns the i-th response line parsed with parser.Parse.
+func (r *Response) Map(i int) (map[string]interface{}, error) {
+	ms, err := r.Maps()
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(ms) {
+		return nil, fmt.Errorf("line %d out of range", i)
+	}
+
+	return ms[i], nil
+}
+
+// Do sends an arbitrary command to the server and waits for its response.
+// Arguments are encoded the same way as by other methods, so only
+// strings, integers and booleans are supported. If ctx is done before
+// the response is received Do returns ctx.Err() and the response is
+// discarded when it arrives.
+func (c *Chubby) Do(ctx context.Context, name string,
+	args ...interface{}) (*Response, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := c.sess.Load()
+	if s == nil {
+		return nil, ErrNotConnected
+	}
+	cl := newCall(name, args...)
+	if err := s.send(cl); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-cl.done:
+		if cl.err != nil {
+			return nil, cl.err
+		}
+		return cl.response(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *call) response() *Response {
+	return &Response{Command: c.name, Lines: c.lines}
+}
@@ -0,0 +1,192 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vchimishuk/chubby/textconn"
+)
+
+// testServer is a local fake Chub daemon. Every accepted connection
+// is served by the handler in its own goroutine.
+type testServer struct {
+	ln      net.Listener
+	handler func(conn *textconn.TextConn)
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	conns   []net.Conn
+}
+
+// newTestServer starts a server which answers the capabilities probe
+// sent on Connect with the stopped status and then passes the connection
+// to the handler.
+func newTestServer(t testing.TB, handler func(conn *textconn.TextConn)) *testServer {
+	return newRawTestServer(t, func(conn *textconn.TextConn) {
+		if _, err := conn.ReadLine(); err != nil {
+			return
+		}
+		if writeResp(conn, "OK", probeStatus) != nil {
+			return
+		}
+		handler(conn)
+	})
+}
+
+const probeStatus = `state: "stopped", volume: 100`
+
+func newRawTestServer(t testing.TB, handler func(conn *textconn.TextConn)) *testServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{ln: ln, handler: handler}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.close)
+
+	return s
+}
+
+func (s *testServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testServer) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handler(textconn.New(conn))
+		}()
+	}
+}
+
+func (s *testServer) close() {
+	s.ln.Close()
+	s.mu.Lock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *testServer) connect(t testing.TB) *Chubby {
+	c := &Chubby{}
+	if err := c.Connect("127.0.0.1", s.port()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+// lookupFunc returns the response header and body lines for
+// the command line.
+type lookupFunc func(line string) (string, []string)
+
+// lookupResps returns a lookupFunc which answers every command found
+// in resps with the given body lines and every other command with
+// an error.
+func lookupResps(resps map[string][]string) lookupFunc {
+	return func(line string) (string, []string) {
+		body, ok := resps[strings.SplitN(line, " ", 2)[0]]
+		if !ok {
+			return "ERR unknown command", nil
+		}
+		return "OK", body
+	}
+}
+
+// responder returns a handler which answers every command found in
+// resps with the given body lines and every other command with an error.
+func responder(resps map[string][]string) func(conn *textconn.TextConn) {
+	lookup := lookupResps(resps)
+
+	return func(conn *textconn.TextConn) {
+		for {
+			line, err := conn.ReadLine()
+			if err != nil {
+				return
+			}
+			header, body := lookup(line)
+			writeResp(conn, header, body...)
+		}
+	}
+}
+
+func writeResp(conn *textconn.TextConn, header string, lines ...string) error {
+	if _, err := conn.WriteLine(header); err != nil {
+		return err
+	}
+	if header != "ERR" && !strings.HasPrefix(header, "ERR ") {
+		for _, l := range lines {
+			if _, err := conn.WriteLine(l); err != nil {
+				return err
+			}
+		}
+		if _, err := conn.WriteLine(""); err != nil {
+			return err
+		}
+	}
+
+	return conn.Flush()
+}
+
+// checkLeaks fails the test if goroutines started during the test are
+// still running after all other cleanup functions finished. It must be
+// called before any other resources are registered for cleanup.
+func checkLeaks(t *testing.T) {
+	n := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(2 * time.Second)
+		for runtime.NumGoroutine() > n {
+			if time.Now().After(deadline) {
+				buf := make([]byte, 1<<16)
+				buf = buf[:runtime.Stack(buf, true)]
+				t.Fatalf("goroutine leak: %d > %d\n%s",
+					runtime.NumGoroutine(), n, buf)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func waitDone(t *testing.T, c *Chubby) {
+	select {
+	case <-c.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection is not done")
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vchimishuk/chubby/textconn"
+)
+
+// call is a single command sent to the server and waiting for its response.
+type call struct {
+	name  string
+	line  string
+	lines []string
+	err   error
+	done  chan struct{}
+}
+
+func newCall(name string, args ...interface{}) *call {
+	line := name
+	for _, arg := range args {
+		line += fmt.Sprintf(" %#v", arg)
+	}
+
+	return &call{name: name, line: line, done: make(chan struct{})}
+}
+
+func (c *call) complete(lines []string, err error) {
+	c.lines = lines
+	c.err = err
+	close(c.done)
+}
+
+// session represents a single connection to the server. Every
+// session is terminated exactly once by shutdown(), either by the client
+// or by the read() goroutine on a fatal error, and is never reused.
+type session struct {
+	conn   *textconn.TextConn
+	caps   *capabilities
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+	// Background goroutines of the session.
+	wg sync.WaitGroup
+	// Time of the last line received from the server.
+	seen   atomic.Int64
+	rtt    atomic.Int64
+	missed atomic.Int32
+	// Serializes writes so that pending order matches wire order.
+	wmu     sync.Mutex
+	mu      sync.Mutex
+	pending []*call
+	err     error
+}
+
+func newSession(conn *textconn.TextConn, unknown string) *session {
+	return &session{
+		conn:   conn,
+		caps:   newCapabilities(unknown),
+		events: make(chan Event, eventsChSize),
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *session) start(keepAlive time.Duration, misses int) {
+	s.seen.Store(time.Now().UnixNano())
+	s.wg.Add(1)
+	go s.read()
+	if keepAlive > 0 {
+		s.wg.Add(1)
+		go s.heartbeat(keepAlive, misses)
+	}
+}
+
+// send writes the given calls to the server. Every call is completed
+// eventually, either by the response or by the error.
+func (s *session) send(calls ...*call) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+
+	var supported []*call
+	for _, c := range calls {
+		if s.caps.unsupported(c.name) {
+			c.complete(nil, fmt.Errorf("%w: %s", ErrUnsupported, c.name))
+		} else {
+			supported = append(supported, c)
+		}
+	}
+	calls = supported
+	if len(calls) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		for _, c := range calls {
+			c.complete(nil, ErrNotConnected)
+		}
+		return ErrNotConnected
+	}
+	s.pending = append(s.pending, calls...)
+	err := s.conn.Await(true)
+	s.mu.Unlock()
+	if err != nil {
+		s.shutdown(err)
+		return err
+	}
+
+	for _, c := range calls {
+		if _, err := s.conn.WriteLine(c.line); err != nil {
+			s.shutdown(err)
+			return err
+		}
+	}
+	if err := s.conn.Flush(); err != nil {
+		s.shutdown(err)
+		return err
+	}
+
+	return nil
+}
+
+// shutdown terminates the session with the given reason failing all
+// pending calls. Only the first call has any effect and returns the
+// result of closing the underlying connection.
+func (s *session) shutdown(reason error) error {
+	var err error
+
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.err = reason
+		pending := s.pending
+		s.pending = nil
+		s.mu.Unlock()
+
+		err = s.conn.Close()
+		for _, c := range pending {
+			c.complete(nil, reason)
+		}
+		close(s.done)
+	})
+
+	return err
+}
+
+func (s *session) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *session) reason() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+func (s *session) inflight() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return ""
+	}
+
+	return s.pending[0].name
+}
+
+func (s *session) complete(lines []string, err error) bool {
+	s.mu.Lock()
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return false
+	}
+	c := s.pending[0]
+	s.pending = s.pending[1:]
+	if len(s.pending) == 0 {
+		// Deadline error, if any, is reported by the next read.
+		s.conn.Await(false)
+	}
+	s.mu.Unlock()
+
+	c.complete(lines, s.caps.record(c.name, lines, err))
+
+	return true
+}
+
+func (s *session) read() {
+	defer s.wg.Done()
+	defer close(s.events)
+
+	for {
+		event, lines, err := s.readResp()
+		if err != nil {
+			var serr ServerError
+			var perr *ProtocolError
+			if errors.As(err, &serr) ||
+				(errors.As(err, &perr) && perr.Usable) {
+
+				if s.complete(nil, err) {
+					continue
+				}
+				err = newProtocolError("", "", false,
+					errors.New("unexpected response"))
+			}
+			s.shutdown(err)
+			return
+		}
+
+		if event != "" {
+			s.event(event, lines)
+		} else if !s.complete(lines, nil) {
+			s.shutdown(newProtocolError("",
+				strings.Join(lines, "\n"), false,
+				errors.New("unexpected response")))
+			return
+		}
+	}
+}
+
+func (s *session) event(name string, lines []string) {
+	e, err := parseEvent(name, lines)
+	if errors.Is(err, errUnknownEvent) {
+		// Ignore unknown events.
+		return
+	} else if err != nil {
+		var perr *ProtocolError
+		if !errors.As(err, &perr) {
+			perr = newProtocolError("", strings.Join(lines, "\n"),
+				true, err)
+		}
+		e = &ErrorEvent{s: strings.Join(lines, "\n"), Err: perr}
+	}
+
+	// Drop events nobody reads instead of blocking responses.
+	select {
+	case s.events <- e:
+	default:
+	}
+}
+
+func (s *session) readResp() (string, []string, error) {
+	line, err := s.conn.ReadLine()
+	if err != nil {
+		return "", nil, err
+	}
+	s.seen.Store(time.Now().UnixNano())
+
+	event := ""
+	pts := strings.SplitN(line, " ", 2)
+	if pts[0] == "OK" {
+		// Do nothing.
+	} else if pts[0] == "EVENT" {
+		if len(pts) != 2 {
+			return "", nil, s.headerError(line)
+		}
+		event = pts[1]
+	} else if pts[0] == "ERR" {
+		if len(pts) != 2 {
+			return "", nil, s.headerError(line)
+		}
+		return "", nil, NewServerError(pts[1])
+	} else {
+		return "", nil, s.headerError(line)
+	}
+
+	lines := make([]string, 0, 8)
+	for {
+		line, err := s.conn.ReadLine()
+		if err != nil {
+			return "", nil, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	return event, lines, nil
+}
+
+func (s *session) headerError(line string) error {
+	return newProtocolError(s.inflight(), line, false,
+		errors.New("invalid header"))
+}
@@ -0,0 +1,279 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/vchimishuk/chubby/internal/fold"
+)
+
+// EntryCompare compares two entries and returns a negative number
+// if a goes before b, a positive number if b goes before a and zero
+// if their order is not defined by the comparison.
+type EntryCompare func(a, b Entry) int
+
+// SortEntries sorts entries in place using the comparisons in turn:
+// the next one is used only if the previous ones consider entries
+// equal. The sort is stable. Without comparisons entries are sorted
+// in the album order: directories first, tracks by number, then
+// everything by name.
+func SortEntries(entries []Entry, cmps ...EntryCompare) {
+	if len(cmps) == 0 {
+		cmps = []EntryCompare{DirsFirst, ByNumber, ByName}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		for _, cmp := range cmps {
+			if c := cmp(entries[i], entries[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// DirsFirst puts directories before tracks.
+func DirsFirst(a, b Entry) int {
+	if a.IsDir() == b.IsDir() {
+		return 0
+	}
+	if a.IsDir() {
+		return -1
+	}
+
+	return 1
+}
+
+// ByNumber orders tracks by the number on the album. Tracks without
+// number go last, directories are considered equal to everything.
+func ByNumber(a, b Entry) int {
+	ta, ok := AsTrack(a)
+	if !ok {
+		return 0
+	}
+	tb, ok := AsTrack(b)
+	if !ok {
+		return 0
+	}
+
+	return compareKnown(ta.Number, tb.Number)
+}
+
+// ByYear orders tracks by the year. Tracks without year go last,
+// directories are considered equal to everything.
+func ByYear(a, b Entry) int {
+	ta, ok := AsTrack(a)
+	if !ok {
+		return 0
+	}
+	tb, ok := AsTrack(b)
+	if !ok {
+		return 0
+	}
+
+	return compareKnown(ta.Year, tb.Year)
+}
+
+// ByName orders entries by the name inside their directories using
+// the default collation.
+func ByName(a, b Entry) int {
+	return defaultCollator.ByName(a, b)
+}
+
+// ByArtist orders tracks by the artist using the default collation.
+// Directories are considered equal to everything.
+func ByArtist(a, b Entry) int {
+	return defaultCollator.ByArtist(a, b)
+}
+
+// compareKnown compares positive numbers, zero means unknown and
+// is greater than any known number.
+func compareKnown(a, b int) int {
+	switch {
+	case a == b:
+		return 0
+	case a == 0:
+		return 1
+	case b == 0:
+		return -1
+	case a < b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Collator compares strings in the natural order: case and diacritics
+// are ignored unless strings differ only by them, and runs of digits
+// are compared as numbers, so "Track 2" goes before "Track 10".
+type Collator struct {
+	// tailoring maps lowercase letters which the language sorts
+	// as separate ones to their sort keys.
+	tailoring map[rune]string
+}
+
+// Sort keys of tailored letters. Characters after 'z' put letters
+// at the end of the alphabet, 0x7f right after the base letter.
+var (
+	nordicTailoring = map[rune]string{
+		'å': "{", 'ä': "|", 'æ': "|", 'ö': "}", 'ø': "}",
+	}
+	danishTailoring = map[rune]string{
+		'æ': "{", 'ä': "{", 'ø': "|", 'ö': "|", 'å': "}",
+	}
+	spanishTailoring = map[rune]string{
+		'ñ': "n\x7f",
+	}
+	tailorings = map[string]map[rune]string{
+		"sv": nordicTailoring,
+		"fi": nordicTailoring,
+		"da": danishTailoring,
+		"nb": danishTailoring,
+		"nn": danishTailoring,
+		"no": danishTailoring,
+		"es": spanishTailoring,
+	}
+)
+
+var defaultCollator = NewCollator("")
+
+// NewCollator returns a collator for the language given as
+// an IETF tag or a POSIX locale name, like "sv-SE" or "sv_SE.UTF-8".
+// Swedish, Finnish, Danish, Norwegian and Spanish alphabets are
+// supported, other languages use the default collation, which sorts
+// letters with diacritics along with their base letters.
+func NewCollator(lang string) *Collator {
+	if i := strings.IndexAny(lang, "-_."); i >= 0 {
+		lang = lang[:i]
+	}
+
+	return &Collator{tailoring: tailorings[strings.ToLower(lang)]}
+}
+
+// Compare returns a negative number if a goes before b, a positive
+// number if b goes before a and zero if the strings are equal.
+func (c *Collator) Compare(a, b string) int {
+	if r := compareNatural(c.key(a), c.key(b)); r != 0 {
+		return r
+	}
+	if r := compareNatural(strings.ToLower(a), strings.ToLower(b)); r != 0 {
+		return r
+	}
+
+	return strings.Compare(a, b)
+}
+
+// ByName orders entries by the name inside their directories.
+func (c *Collator) ByName(a, b Entry) int {
+	return c.Compare(entryName(a), entryName(b))
+}
+
+// ByArtist orders tracks by the artist. Directories are considered
+// equal to everything.
+func (c *Collator) ByArtist(a, b Entry) int {
+	ta, ok := AsTrack(a)
+	if !ok {
+		return 0
+	}
+	tb, ok := AsTrack(b)
+	if !ok {
+		return 0
+	}
+
+	return c.Compare(ta.Artist, tb.Artist)
+}
+
+// key returns the primary sort key of s.
+func (c *Collator) key(s string) string {
+	if c.tailoring == nil {
+		return fold.String(s)
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if k, ok := c.tailoring[unicode.ToLower(r)]; ok {
+			b.WriteString(k)
+		} else {
+			b.WriteString(fold.String(string(r)))
+		}
+	}
+
+	return b.String()
+}
+
+// compareNatural compares strings byte by byte except runs of ASCII
+// digits, which are compared by their numeric values. If strings are
+// equal this way the one with fewer leading zeros goes first.
+func compareNatural(a, b string) int {
+	zeros := 0
+	for len(a) > 0 && len(b) > 0 {
+		if !isDigit(a[0]) || !isDigit(b[0]) {
+			if a[0] != b[0] {
+				return compareInts(int(a[0]), int(b[0]))
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		da, db := digitsLen(a), digitsLen(b)
+		na := strings.TrimLeft(a[:da], "0")
+		nb := strings.TrimLeft(b[:db], "0")
+		if len(na) != len(nb) {
+			return compareInts(len(na), len(nb))
+		}
+		if r := strings.Compare(na, nb); r != 0 {
+			return r
+		}
+		if zeros == 0 {
+			zeros = compareInts(da, db)
+		}
+		a, b = a[da:], b[db:]
+	}
+	if len(a) != len(b) {
+		return compareInts(len(a), len(b))
+	}
+
+	return zeros
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func digitsLen(s string) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+
+	return n
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
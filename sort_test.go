@@ -0,0 +1,129 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func entryNames(entries []Entry) string {
+	var ns []string
+	for _, e := range entries {
+		ns = append(ns, entryName(e))
+	}
+
+	return strings.Join(ns, ", ")
+}
+
+func TestAsEntry(t *testing.T) {
+	var d Entry = &Dir{Path: "/a"}
+	if _, ok := AsTrack(d); ok {
+		t.Fatal("dir is a track")
+	}
+	if dir, ok := AsDir(d); !ok || dir.Path != "/a" {
+		t.Fatal("dir is not a dir")
+	}
+
+	var tr Entry = &Track{Path: "/a.flac"}
+	if _, ok := AsDir(tr); ok {
+		t.Fatal("track is a dir")
+	}
+	if track, ok := AsTrack(tr); !ok || track.Path != "/a.flac" {
+		t.Fatal("track is not a track")
+	}
+
+	var nd Entry = (*Dir)(nil)
+	var nt Entry = (*Track)(nil)
+	if _, ok := AsDir(nd); ok {
+		t.Fatal("nil dir is a dir")
+	}
+	if _, ok := AsTrack(nt); ok {
+		t.Fatal("nil track is a track")
+	}
+	if _, ok := AsDir(nil); ok {
+		t.Fatal("nil entry is a dir")
+	}
+}
+
+func TestSortEntries(t *testing.T) {
+	entries := []Entry{
+		&Track{Path: "/a/bonus.flac"},
+		&Track{Path: "/a/10 - Ten.flac", Number: 10},
+		&Dir{Path: "/a/CD 10"},
+		&Track{Path: "/a/2 - Two.flac", Number: 2},
+		&Dir{Path: "/a/CD 2"},
+		&Track{Path: "/a/01 - One.flac", Number: 1},
+		&Dir{Path: "/a/cd 1"},
+	}
+	SortEntries(entries)
+	exp := "cd 1, CD 2, CD 10, 01 - One.flac, 2 - Two.flac, " +
+		"10 - Ten.flac, bonus.flac"
+	if s := entryNames(entries); s != exp {
+		t.Fatalf("unexpected order: %s", s)
+	}
+
+	entries = []Entry{
+		&Track{Path: "/3", Artist: "Kraftwerk", Year: 1974},
+		&Track{Path: "/1", Artist: "björk", Year: 1995},
+		&Track{Path: "/4", Year: 1971},
+		&Track{Path: "/2", Artist: "Björk", Year: 1993},
+		&Track{Path: "/5", Artist: "Kraftwerk"},
+	}
+	SortEntries(entries, ByArtist, ByYear)
+	if s := entryNames(entries); s != "4, 2, 1, 3, 5" {
+		t.Fatalf("unexpected order: %s", s)
+	}
+	SortEntries(entries, ByYear, ByName)
+	if s := entryNames(entries); s != "4, 3, 2, 1, 5" {
+		t.Fatalf("unexpected order: %s", s)
+	}
+}
+
+func TestCollator(t *testing.T) {
+	tests := []struct {
+		lang  string
+		words []string
+	}{
+		{"", []string{"1", "01", "2", "10", "a", "Åland", "b", "Öl", "zoo"}},
+		{"", []string{"Disc 1 Track 2", "Disc 1 Track 10", "Disc 2", "disc 2"}},
+		{"", []string{"Ab", "ab", "Áb", "áb", "abc"}},
+		{"sv_SE.UTF-8", []string{"a", "b", "zoo", "Åland", "ära", "Öl"}},
+		{"da-DK", []string{"a", "zoo", "Æble", "ø", "Århus"}},
+		{"es", []string{"nube", "nzz", "ñu", "oso"}},
+	}
+	for _, test := range tests {
+		c := NewCollator(test.lang)
+		var words []string
+		for i := len(test.words) - 1; i >= 0; i-- {
+			words = append(words, test.words[i])
+		}
+		sort.Slice(words, func(i, j int) bool {
+			return c.Compare(words[i], words[j]) < 0
+		})
+		if strings.Join(words, " ") != strings.Join(test.words, " ") {
+			t.Errorf("%q: unexpected order: %q", test.lang, words)
+		}
+		for _, w := range test.words {
+			if c.Compare(w, w) != 0 {
+				t.Errorf("%q: %q != %q", test.lang, w, w)
+			}
+		}
+	}
+}
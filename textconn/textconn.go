@@ -1,4 +1,4 @@
-// Copyright 2016 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+// Copyright 2016-2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
 //
 // This file is part of chubby.
 //
@@ -19,29 +19,98 @@ package textconn
 
 import (
 	"bufio"
+	"errors"
 	"net"
-	"net/textproto"
+	"sync"
+	"time"
 )
 
+var ErrLineTooLong = errors.New("line too long")
+
+// TextConn is a line oriented connection. Timeouts and line length limit
+// must be configured before the connection is used, zero values
+// disable them.
 type TextConn struct {
-	conn   net.Conn
-	reader *textproto.Reader
-	writer *bufio.Writer
+	conn         net.Conn
+	reader       *bufio.Reader
+	writer       *bufio.Writer
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	idleTimeout  time.Duration
+	maxLineLen   int
+	// Serializes await changes with read deadline updates, so
+	// a deadline computed from a stale await value is never set.
+	mu    sync.Mutex
+	await bool
 }
 
 func New(conn net.Conn) *TextConn {
 	return &TextConn{
 		conn:   conn,
-		reader: textproto.NewReader(bufio.NewReader(conn)),
+		reader: bufio.NewReader(conn),
 		writer: bufio.NewWriter(conn),
 	}
 }
 
+// SetReadTimeout sets the maximum time to wait for every line while
+// a reply is awaited (see Await).
+func (c *TextConn) SetReadTimeout(d time.Duration) {
+	c.readTimeout = d
+}
+
+// SetWriteTimeout sets the maximum time every write operation can take.
+func (c *TextConn) SetWriteTimeout(d time.Duration) {
+	c.writeTimeout = d
+}
+
+// SetIdleTimeout sets the maximum time to wait for the next line
+// regardless of whether a reply is awaited or not.
+func (c *TextConn) SetIdleTimeout(d time.Duration) {
+	c.idleTimeout = d
+}
+
+func (c *TextConn) SetMaxLineLength(n int) {
+	c.maxLineLen = n
+}
+
+// Await marks if a reply from the peer is expected. It can be called
+// concurrently with ReadLine and updates the deadline of a pending read.
+func (c *TextConn) Await(b bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.await = b
+
+	return c.conn.SetReadDeadline(c.readDeadline())
+}
+
 func (c *TextConn) ReadLine() (string, error) {
-	return c.reader.ReadLine()
+	if err := c.setReadDeadline(); err != nil {
+		return "", err
+	}
+
+	var line []byte
+	for {
+		l, more, err := c.reader.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		if c.maxLineLen > 0 && len(line)+len(l) > c.maxLineLen {
+			return "", ErrLineTooLong
+		}
+		line = append(line, l...)
+		if !more {
+			break
+		}
+	}
+
+	return string(line), nil
 }
 
 func (c *TextConn) WriteLine(line string) (int, error) {
+	if err := c.setWriteDeadline(); err != nil {
+		return 0, err
+	}
 	n, err := c.writer.WriteString(line)
 	if err != nil {
 		return n, err
@@ -52,9 +121,44 @@ func (c *TextConn) WriteLine(line string) (int, error) {
 }
 
 func (c *TextConn) Flush() error {
+	if err := c.setWriteDeadline(); err != nil {
+		return err
+	}
+
 	return c.writer.Flush()
 }
 
 func (c *TextConn) Close() error {
 	return c.conn.Close()
 }
+
+func (c *TextConn) setReadDeadline() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.conn.SetReadDeadline(c.readDeadline())
+}
+
+// readDeadline must be called with mu held.
+func (c *TextConn) readDeadline() time.Time {
+	var d time.Duration
+	if c.await {
+		d = c.readTimeout
+	}
+	if c.idleTimeout > 0 && (d == 0 || c.idleTimeout < d) {
+		d = c.idleTimeout
+	}
+	if d == 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(d)
+}
+
+func (c *TextConn) setWriteDeadline() error {
+	if c.writeTimeout == 0 {
+		return nil
+	}
+
+	return c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package textconn
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func pipe(t *testing.T) (*TextConn, net.Conn) {
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+
+	return New(a), b
+}
+
+// readLine reads a line in the background.
+func readLine(c *TextConn) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		_, err := c.ReadLine()
+		ch <- err
+	}()
+
+	return ch
+}
+
+func isTimeout(err error) bool {
+	var nerr net.Error
+
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
+
+func TestReadWriteLine(t *testing.T) {
+	c, peer := pipe(t)
+	go func() {
+		peer.Write([]byte("hello\n" + strings.Repeat("x", 5000) + "\n"))
+	}()
+	if l, err := c.ReadLine(); err != nil || l != "hello" {
+		t.Fatalf("unexpected line: %q, %v", l, err)
+	}
+	if l, err := c.ReadLine(); err != nil || len(l) != 5000 {
+		t.Fatalf("unexpected line: %d, %v", len(l), err)
+	}
+
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := peer.Read(buf)
+		peer.Write(buf[:n])
+	}()
+	if _, err := c.WriteLine("ping"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if l, err := c.ReadLine(); err != nil || l != "ping" {
+		t.Fatalf("unexpected line: %q, %v", l, err)
+	}
+}
+
+func TestLineTooLong(t *testing.T) {
+	c, peer := pipe(t)
+	c.SetMaxLineLength(8)
+	go func() {
+		peer.Write([]byte("12345678\n123456789\n"))
+	}()
+	if l, err := c.ReadLine(); err != nil || l != "12345678" {
+		t.Fatalf("unexpected line: %q, %v", l, err)
+	}
+	if _, err := c.ReadLine(); err != ErrLineTooLong {
+		t.Fatalf("%v != %v", err, ErrLineTooLong)
+	}
+
+	// Lines longer than the reader buffer are limited too.
+	c, peer = pipe(t)
+	c.SetMaxLineLength(5000)
+	go func() {
+		peer.Write([]byte(strings.Repeat("x", 5001) + "\n"))
+	}()
+	if _, err := c.ReadLine(); err != ErrLineTooLong {
+		t.Fatalf("%v != %v", err, ErrLineTooLong)
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	c, peer := pipe(t)
+	c.SetReadTimeout(20 * time.Millisecond)
+
+	// Read timeout applies only while a reply is awaited.
+	ch := readLine(c)
+	select {
+	case err := <-ch:
+		t.Fatalf("unexpected read result: %v", err)
+	case <-time.After(60 * time.Millisecond):
+	}
+	// Awaiting updates the deadline of the pending read.
+	if err := c.Await(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-ch; !isTimeout(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := c.ReadLine(); !isTimeout(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond || d > time.Second {
+		t.Fatalf("unexpected timeout: %v", d)
+	}
+
+	if err := c.Await(false); err != nil {
+		t.Fatal(err)
+	}
+	ch = readLine(c)
+	time.Sleep(60 * time.Millisecond)
+	go peer.Write([]byte("late\n"))
+	if err := <-ch; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	c, _ := pipe(t)
+	c.SetIdleTimeout(20 * time.Millisecond)
+	c.SetReadTimeout(time.Minute)
+
+	// The shortest of the timeouts is used.
+	for _, await := range []bool{false, true} {
+		if err := c.Await(await); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-readLine(c):
+			if !isTimeout(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("idle timeout expected")
+		}
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	c, _ := pipe(t)
+	c.SetWriteTimeout(20 * time.Millisecond)
+
+	// Nobody reads the other end, so the write blocks.
+	if _, err := c.WriteLine("hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Flush(); !isTimeout(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package time
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Predefined layouts for Format.
+const (
+	// 1:02:03 or 2:03, same as String.
+	Adaptive = "%a"
+	// 01:02:03
+	Fixed = "%H:%M:%S"
+	// 1:02:03, 0:02:03
+	Hours = "%h:%M:%S"
+	// 62:03
+	Minutes = "%t:%S"
+	// 1 h 2 min 3 s
+	Human = "%v"
+)
+
+// Format returns t formatted according to the layout. Layout verbs are:
+//
+//	%h  hours
+//	%H  hours, two digits
+//	%m  minutes of the hour
+//	%M  minutes of the hour, two digits
+//	%s  seconds of the minute
+//	%S  seconds of the minute, two digits
+//	%t  total minutes
+//	%T  total minutes, two digits
+//	%a  adaptive form as returned by String
+//	%v  human readable form, e.g. "3 min 5 s"
+//	%%  percent sign
+//
+// Negative time is formatted as its absolute value prefixed with '-'.
+func (t Time) Format(layout string) string {
+	if t < 0 {
+		return "-" + (-t).Format(layout)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		c := layout[i]
+		if c != '%' || i == len(layout)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch layout[i] {
+		case 'h':
+			b.WriteString(strconv.Itoa(t.Hour()))
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'm':
+			b.WriteString(strconv.Itoa(t.Minute()))
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 's':
+			b.WriteString(strconv.Itoa(t.Second()))
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 't':
+			b.WriteString(strconv.Itoa(int(t) / 60))
+		case 'T':
+			fmt.Fprintf(&b, "%02d", int(t)/60)
+		case 'a':
+			b.WriteString(t.String())
+		case 'v':
+			b.WriteString(t.human())
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(layout[i])
+		}
+	}
+
+	return b.String()
+}
+
+func (t Time) human() string {
+	var pts []string
+	if h := t.Hour(); h > 0 {
+		pts = append(pts, strconv.Itoa(h)+" h")
+	}
+	if m := t.Minute(); m > 0 {
+		pts = append(pts, strconv.Itoa(m)+" min")
+	}
+	if s := t.Second(); s > 0 || len(pts) == 0 {
+		pts = append(pts, strconv.Itoa(s)+" s")
+	}
+
+	return strings.Join(pts, " ")
+}
+
+// MarshalText encodes t in the form returned by String, e.g. "1:02:03".
+// It differs from MarshalJSON, which encodes a number of seconds
+// to keep JSON durations numeric. UnmarshalJSON accepts both forms.
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Time) UnmarshalText(b []byte) error {
+	s := string(b)
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	tm, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	if neg {
+		tm = -tm
+	}
+	*t = tm
+
+	return nil
+}
+
+// MarshalJSON encodes t as a number of seconds, unlike MarshalText
+// which produces the String form.
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(t))), nil
+}
+
+// UnmarshalJSON accepts a number of seconds or a string
+// in the format accepted by UnmarshalText.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return t.UnmarshalText([]byte(s))
+	}
+
+	var n int
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*t = Time(n)
+
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package time
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	stdtime "time"
+)
+
+// Millis is a time in milliseconds. It is used where Time with its
+// one second precision is not enough, e.g. for playback position.
+type Millis int64
+
+// Millis converts t to milliseconds.
+func (t Time) Millis() Millis {
+	return Millis(t) * 1000
+}
+
+// MillisFromSeconds converts fractional seconds to Millis rounding
+// it to the nearest millisecond, halfway values are rounded away
+// from zero.
+func MillisFromSeconds(s float64) Millis {
+	v := s * 1000
+	if v < 0 {
+		return Millis(v - 0.5)
+	}
+
+	return Millis(v + 0.5)
+}
+
+// MillisFromDuration converts d to Millis rounding it to the nearest
+// millisecond, halfway values are rounded away from zero.
+func MillisFromDuration(d stdtime.Duration) Millis {
+	return Millis(d.Round(stdtime.Millisecond) / stdtime.Millisecond)
+}
+
+// Time returns m truncated to whole seconds.
+func (m Millis) Time() Time {
+	return Time(m / 1000)
+}
+
+// Seconds returns m as fractional seconds.
+func (m Millis) Seconds() float64 {
+	return float64(m) / 1000
+}
+
+func (m Millis) Duration() stdtime.Duration {
+	return stdtime.Duration(m) * stdtime.Millisecond
+}
+
+// String returns m in [[h:]m]:ss.mmm format.
+func (m Millis) String() string {
+	if m < 0 {
+		return "-" + (-m).String()
+	}
+
+	return fmt.Sprintf("%s.%03d", m.Time(), m%1000)
+}
+
+// ParseMillis parses time in [[h:]m:]s[.fff] format. Fraction can
+// have up to three digits.
+func ParseMillis(s string) (Millis, error) {
+	sec, frac, ok := strings.Cut(s, ".")
+	t, err := Parse(sec)
+	if err != nil {
+		return 0, err
+	}
+	if ok && (len(frac) == 0 || len(frac) > 3) {
+		return 0, errors.New("milliseconds: bad format")
+	}
+
+	var ms Millis
+	for i := 0; i < 3; i++ {
+		ms *= 10
+		if i < len(frac) {
+			if frac[i] < '0' || frac[i] > '9' {
+				return 0, errors.New("milliseconds: bad format")
+			}
+			ms += Millis(frac[i] - '0')
+		}
+	}
+
+	return t.Millis() + ms, nil
+}
+
+// MarshalText encodes m in the form returned by String, e.g. "1:02.345".
+// It differs from MarshalJSON, which encodes a number of milliseconds
+// to keep JSON durations numeric. UnmarshalJSON accepts both forms.
+func (m Millis) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Millis) UnmarshalText(b []byte) error {
+	s := string(b)
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	ms, err := ParseMillis(s)
+	if err != nil {
+		return err
+	}
+	if neg {
+		ms = -ms
+	}
+	*m = ms
+
+	return nil
+}
+
+// MarshalJSON encodes m as a number of milliseconds, unlike MarshalText
+// which produces the String form.
+func (m Millis) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(int64(m), 10)), nil
+}
+
+// UnmarshalJSON accepts a number of milliseconds or a string
+// in the format accepted by UnmarshalText.
+func (m *Millis) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return m.UnmarshalText([]byte(s))
+	}
+
+	var n int64
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*m = Millis(n)
+
+	return nil
+}
@@ -1,4 +1,4 @@
-// Copyright 2017 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+// Copyright 2017-2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
 //
 // This file is part of chubby.
 //
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	stdtime "time"
 )
 
 type Time int
@@ -39,6 +40,10 @@ func (t Time) Second() int {
 }
 
 func (t Time) String() string {
+	if t < 0 {
+		return "-" + (-t).String()
+	}
+
 	r := ""
 	h := t.Hour()
 	m := t.Minute()
@@ -61,36 +66,209 @@ func New(seconds int) Time {
 	return Time(seconds)
 }
 
+// FromDuration converts d to Time rounding it to the nearest second,
+// halfway values are rounded away from zero.
+func FromDuration(d stdtime.Duration) Time {
+	return Time(d.Round(stdtime.Second) / stdtime.Second)
+}
+
+func (t Time) Duration() stdtime.Duration {
+	return stdtime.Duration(t) * stdtime.Second
+}
+
+func (t Time) Add(u Time) Time {
+	return t + u
+}
+
+func (t Time) Sub(u Time) Time {
+	return t - u
+}
+
+func (t Time) Abs() Time {
+	if t < 0 {
+		return -t
+	}
+
+	return t
+}
+
+// Clamp returns t limited to the [min, max] range.
+func (t Time) Clamp(min, max Time) Time {
+	if t < min {
+		return min
+	}
+	if t > max {
+		return max
+	}
+
+	return t
+}
+
+// Percent returns t as a percentage of total, or 0 if total is not
+// positive. The result is not limited to the [0, 100] range.
+func (t Time) Percent(total Time) float64 {
+	if total <= 0 {
+		return 0
+	}
+
+	return float64(t) * 100 / float64(total)
+}
+
+// OfPercent returns the given percentage of t rounded to
+// the nearest second.
+func (t Time) OfPercent(p float64) Time {
+	v := float64(t) * p / 100
+	if v < 0 {
+		return Time(v - 0.5)
+	}
+
+	return Time(v + 0.5)
+}
+
+// Compare returns -1, 0 or +1 if t is less than, equal to
+// or greater than u respectively.
+func (t Time) Compare(u Time) int {
+	if t < u {
+		return -1
+	}
+	if t > u {
+		return 1
+	}
+
+	return 0
+}
+
+func (t Time) Before(u Time) bool {
+	return t < u
+}
+
+func (t Time) After(u Time) bool {
+	return t > u
+}
+
+// Parse parses time in [[h:]m:]s format. The most significant
+// component is not limited, so "90" and "90:00" are valid values.
 func Parse(s string) (Time, error) {
+	units := []string{"seconds", "minutes", "hours"}
+	mults := []int{1, 60, 60 * 60}
+
 	pts := reverse(strings.Split(s, ":"))
-	i, err := parseSecMin(pts[0])
-	if err != nil {
-		return 0, fmt.Errorf("seconds: %w", err)
+	if len(pts) > len(units) {
+		return 0, errors.New("bad format")
 	}
-	t := i
 
-	if len(pts) > 1 {
-		i, err := parseSecMin(pts[1])
+	t := 0
+	for i, p := range pts {
+		var n int
+		var err error
+		if i == len(pts)-1 {
+			n, err = parseLeading(p)
+		} else {
+			n, err = parseSecMin(p)
+		}
 		if err != nil {
-			return 0, fmt.Errorf("minutes: %w", err)
+			return 0, fmt.Errorf("%s: %w", units[i], err)
 		}
-		t += i * 60
+		t += n * mults[i]
 	}
-	if len(pts) > 2 {
-		i, err := parseSecMin(pts[2])
+
+	return Time(t), nil
+}
+
+// Mode of a Position. Values of the first three modes match
+// corresponding chubby.SeekMode values.
+type Mode int
+
+const (
+	ModeAbs Mode = iota
+	ModeBackward
+	ModeForward
+	ModePercent
+)
+
+// Position is a playback position as typed by a user.
+type Position struct {
+	Mode Mode
+	// Time is set for all modes except ModePercent.
+	Time Time
+	// Percent of the track length is set for ModePercent only.
+	Percent float64
+}
+
+// ParsePosition parses absolute or relative playback position.
+// Supported formats are: [[h:]m:]s ("1:30"), units ("90s", "1h2m",
+// "2m30s") and percentage of the track length ("50%"). Time can be
+// prefixed with + or - sign to make it relative to the current position.
+func ParsePosition(s string) (Position, error) {
+	var p Position
+
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
 		if err != nil {
-			return 0, fmt.Errorf("hours: %w", err)
+			return p, errors.New("bad percentage")
 		}
-		t += i * 60 * 60
+		// Written this way to reject NaN too.
+		if !(f >= 0 && f <= 100) || s[0] == '+' {
+			return p, errors.New("percentage out of range")
+		}
+		p.Mode = ModePercent
+		p.Percent = f
+
+		return p, nil
 	}
-	if len(pts) > 3 {
-		return 0, errors.New("bad format")
+
+	if strings.HasPrefix(s, "+") {
+		p.Mode = ModeForward
+		s = s[1:]
+	} else if strings.HasPrefix(s, "-") {
+		p.Mode = ModeBackward
+		s = s[1:]
+	}
+
+	var err error
+	if strings.ContainsAny(s, "hms") {
+		p.Time, err = parseUnits(s)
+	} else {
+		p.Time, err = Parse(s)
+	}
+	if err != nil {
+		return Position{}, err
+	}
+
+	return p, nil
+}
+
+// parseUnits parses time in 1h2m3s format. Every unit is optional
+// but units must go in that order.
+func parseUnits(s string) (Time, error) {
+	units := "hms"
+	mults := []int{60 * 60, 60, 1}
+
+	t := 0
+	for len(s) > 0 {
+		i := strings.IndexAny(s, units)
+		if i == -1 {
+			return 0, errors.New("unit expected")
+		}
+		u := strings.IndexByte(units, s[i])
+		n, err := parseLeading(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("%c: %w", s[i], err)
+		}
+		t += n * mults[u]
+		units = units[u+1:]
+		mults = mults[u+1:]
+		s = s[i+1:]
 	}
 
 	return Time(t), nil
 }
 
 func parseSecMin(s string) (int, error) {
+	if strings.HasPrefix(s, "+") {
+		return 0, errors.New("unexpected sign")
+	}
 	i, err := strconv.Atoi(s)
 	if err != nil {
 		return 0, err
@@ -105,7 +283,10 @@ func parseSecMin(s string) (int, error) {
 	return i, nil
 }
 
-func parseHour(s string) (int, error) {
+func parseLeading(s string) (int, error) {
+	if strings.HasPrefix(s, "+") {
+		return 0, errors.New("unexpected sign")
+	}
 	i, err := strconv.Atoi(s)
 	if err != nil {
 		return 0, err
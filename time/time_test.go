@@ -1,4 +1,4 @@
-// Copyright 2017 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+// Copyright 2017-2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
 //
 // This file is part of chubby.
 //
@@ -17,7 +17,12 @@
 
 package time
 
-import "testing"
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	stdtime "time"
+)
 
 func TestString(t *testing.T) {
 	assertStrEq(t, Time(60*60).String(), "1:00:00")
@@ -26,6 +31,59 @@ func TestString(t *testing.T) {
 	assertStrEq(t, Time(60+59).String(), "1:59")
 	assertStrEq(t, Time(59).String(), "0:59")
 	assertStrEq(t, Time(0).String(), "0:00")
+	assertStrEq(t, Time(-59).String(), "-0:59")
+	assertStrEq(t, Time(-60*60-1).String(), "-1:00:01")
+}
+
+func TestDuration(t *testing.T) {
+	ms := stdtime.Millisecond
+	assertTimeEq(t, Time(0), FromDuration(0))
+	assertTimeEq(t, Time(1), FromDuration(1400*ms))
+	assertTimeEq(t, Time(2), FromDuration(1500*ms))
+	assertTimeEq(t, Time(0), FromDuration(499*ms))
+	assertTimeEq(t, Time(-1), FromDuration(-1400*ms))
+	assertTimeEq(t, Time(-2), FromDuration(-1500*ms))
+	assertTimeEq(t, Time(3723), FromDuration(stdtime.Hour+2*stdtime.Minute+3*stdtime.Second))
+
+	if Time(90).Duration() != 90*stdtime.Second {
+		t.Fatal("90 != 90s")
+	}
+	if Time(-5).Duration() != -5*stdtime.Second {
+		t.Fatal("-5 != -5s")
+	}
+	for _, tm := range []Time{-100, -1, 0, 1, 100} {
+		assertTimeEq(t, tm, FromDuration(tm.Duration()))
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	assertTimeEq(t, Time(70), Time(60).Add(10))
+	assertTimeEq(t, Time(-10), Time(60).Sub(70))
+	assertTimeEq(t, Time(10), Time(-10).Abs())
+	assertTimeEq(t, Time(0), Time(-10).Clamp(0, 100))
+	assertTimeEq(t, Time(100), Time(110).Clamp(0, 100))
+	assertTimeEq(t, Time(50), Time(50).Clamp(0, 100))
+
+	if p := Time(30).Percent(120); p != 25 {
+		t.Fatalf("%f != 25", p)
+	}
+	if p := Time(30).Percent(0); p != 0 {
+		t.Fatalf("%f != 0", p)
+	}
+	if p := Time(-30).Percent(120); p != -25 {
+		t.Fatalf("%f != -25", p)
+	}
+	assertTimeEq(t, Time(2), Time(3).OfPercent(50))
+	assertTimeEq(t, Time(-2), Time(-3).OfPercent(50))
+	assertTimeEq(t, Time(60), Time(240).OfPercent(25))
+
+	if Time(1).Compare(2) != -1 || Time(2).Compare(2) != 0 ||
+		Time(3).Compare(2) != 1 {
+		t.Fatal("invalid Compare")
+	}
+	if !Time(-1).Before(0) || Time(0).After(0) {
+		t.Fatal("invalid Before/After")
+	}
 }
 
 func TestParse(t *testing.T) {
@@ -59,6 +117,271 @@ func TestParse(t *testing.T) {
 	assertStrEq(t, "bad format", err.Error())
 }
 
+func TestParseLeading(t *testing.T) {
+	tests := []struct {
+		s   string
+		t   Time
+		err string
+	}{
+		{"90", 90, ""},
+		{"90:00", 90 * 60, ""},
+		{"100:00:00", 100 * 60 * 60, ""},
+		{"1:60", 0, "seconds: out of range"},
+		{"1:60:00", 0, "minutes: out of range"},
+		{"-1", 0, "seconds: out of range"},
+		{"1:+5", 0, "seconds: unexpected sign"},
+	}
+
+	for _, test := range tests {
+		tm, err := Parse(test.s)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("%s: %v != %s", test.s, err, test.err)
+			}
+		} else {
+			assertErrNil(t, err)
+			assertTimeEq(t, test.t, tm)
+		}
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		s   string
+		p   Position
+		err bool
+	}{
+		{"0", Position{Mode: ModeAbs, Time: 0}, false},
+		{"30", Position{Mode: ModeAbs, Time: 30}, false},
+		{"90", Position{Mode: ModeAbs, Time: 90}, false},
+		{"1:02:03", Position{Mode: ModeAbs, Time: 3723}, false},
+		{" 1:00 ", Position{Mode: ModeAbs, Time: 60}, false},
+		{"+30", Position{Mode: ModeForward, Time: 30}, false},
+		{"-1:00", Position{Mode: ModeBackward, Time: 60}, false},
+		{"90s", Position{Mode: ModeAbs, Time: 90}, false},
+		{"1h2m", Position{Mode: ModeAbs, Time: 3720}, false},
+		{"1h2m3s", Position{Mode: ModeAbs, Time: 3723}, false},
+		{"2m30s", Position{Mode: ModeAbs, Time: 150}, false},
+		{"1h30s", Position{Mode: ModeAbs, Time: 3630}, false},
+		{"90m", Position{Mode: ModeAbs, Time: 5400}, false},
+		{"+10s", Position{Mode: ModeForward, Time: 10}, false},
+		{"-1m", Position{Mode: ModeBackward, Time: 60}, false},
+		{"50%", Position{Mode: ModePercent, Percent: 50}, false},
+		{"0%", Position{Mode: ModePercent, Percent: 0}, false},
+		{"12.5%", Position{Mode: ModePercent, Percent: 12.5}, false},
+		{"100%", Position{Mode: ModePercent, Percent: 100}, false},
+		{"", Position{}, true},
+		{"+", Position{}, true},
+		{"--1", Position{}, true},
+		{"+-1", Position{}, true},
+		{"abc", Position{}, true},
+		{"1m1h", Position{}, true},
+		{"1s1s", Position{}, true},
+		{"h", Position{}, true},
+		{"1h2", Position{}, true},
+		{"1.5m", Position{}, true},
+		{"1:2:3:4", Position{}, true},
+		{"101%", Position{}, true},
+		{"-10%", Position{}, true},
+		{"+10%", Position{}, true},
+		{"%", Position{}, true},
+		{"NaN%", Position{}, true},
+		{"nan%", Position{}, true},
+		{"Inf%", Position{}, true},
+		{"+Inf%", Position{}, true},
+		{"-Inf%", Position{}, true},
+		{"1:60", Position{}, true},
+	}
+
+	for _, test := range tests {
+		p, err := ParsePosition(test.s)
+		if test.err {
+			if err == nil {
+				t.Fatalf("%q: error expected, got %+v", test.s, p)
+			}
+			if p != (Position{}) {
+				t.Fatalf("%q: zero position expected, got %+v", test.s, p)
+			}
+		} else {
+			if err != nil {
+				t.Fatalf("%q: %s", test.s, err)
+			}
+			if p != test.p {
+				t.Fatalf("%q: %+v != %+v", test.s, p, test.p)
+			}
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		t      Time
+		layout string
+		s      string
+	}{
+		{185, Adaptive, "3:05"},
+		{3723, Adaptive, "1:02:03"},
+		{185, Fixed, "00:03:05"},
+		{3723, Fixed, "01:02:03"},
+		{185, Hours, "0:03:05"},
+		{3723, Hours, "1:02:03"},
+		{3723, Minutes, "62:03"},
+		{185, Minutes, "3:05"},
+		{185, Human, "3 min 5 s"},
+		{3720, Human, "1 h 2 min"},
+		{3601, Human, "1 h 1 s"},
+		{0, Human, "0 s"},
+		{-185, Fixed, "-00:03:05"},
+		{-185, Human, "-3 min 5 s"},
+		{65, "%T:%S", "01:05"},
+		{65, "%m min %s sec", "1 min 5 sec"},
+		{65, "100%% %x %", "100% %x %"},
+	}
+
+	for _, test := range tests {
+		assertStrEq(t, test.s, test.t.Format(test.layout))
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	b, err := json.Marshal(struct {
+		Length Time
+		Pos    Time
+	}{185, -5})
+	assertErrNil(t, err)
+	assertStrEq(t, `{"Length":185,"Pos":-5}`, string(b))
+
+	var v struct {
+		A Time
+		B Time
+		C Time
+	}
+	err = json.Unmarshal([]byte(`{"A":185,"B":"1:02:03","C":"-0:05"}`), &v)
+	assertErrNil(t, err)
+	assertTimeEq(t, 185, v.A)
+	assertTimeEq(t, 3723, v.B)
+	assertTimeEq(t, -5, v.C)
+
+	err = json.Unmarshal([]byte(`{"A":"foo"}`), &v)
+	if err == nil {
+		t.Fatal("error expected")
+	}
+
+	b, err = Time(3723).MarshalText()
+	assertErrNil(t, err)
+	assertStrEq(t, "1:02:03", string(b))
+
+	// Both forms survive a round trip.
+	for _, tm := range []Time{0, 59, 3723, -5, -3723} {
+		var v Time
+		b, err := tm.MarshalText()
+		assertErrNil(t, err)
+		assertErrNil(t, v.UnmarshalText(b))
+		assertTimeEq(t, tm, v)
+
+		b, err = json.Marshal(tm)
+		assertErrNil(t, err)
+		assertStrEq(t, strconv.Itoa(int(tm)), string(b))
+		assertErrNil(t, json.Unmarshal(b, &v))
+		assertTimeEq(t, tm, v)
+
+		b, err = json.Marshal(tm.String())
+		assertErrNil(t, err)
+		assertErrNil(t, json.Unmarshal(b, &v))
+		assertTimeEq(t, tm, v)
+	}
+}
+
+func TestMillis(t *testing.T) {
+	ms := stdtime.Millisecond
+	tests := []struct {
+		m Millis
+		v Millis
+	}{
+		{Time(12).Millis(), 12000},
+		{MillisFromSeconds(12.345), 12345},
+		{MillisFromSeconds(0.0005), 1},
+		{MillisFromSeconds(-1.5), -1500},
+		{MillisFromDuration(1500*stdtime.Microsecond + 500*stdtime.Nanosecond), 2},
+		{MillisFromDuration(-1400 * ms), -1400},
+	}
+	for _, test := range tests {
+		if test.m != test.v {
+			t.Fatalf("%d != %d", test.m, test.v)
+		}
+	}
+
+	assertTimeEq(t, Time(12), Millis(12999).Time())
+	assertTimeEq(t, Time(-1), Millis(-1999).Time())
+	if Millis(1500).Duration() != 1500*ms {
+		t.Fatal("1500 != 1.5s")
+	}
+	if Millis(1500).Seconds() != 1.5 {
+		t.Fatal("1500 != 1.5")
+	}
+	assertStrEq(t, Millis(62345).String(), "1:02.345")
+	assertStrEq(t, Millis(3600007).String(), "1:00:00.007")
+	assertStrEq(t, Millis(-500).String(), "-0:00.500")
+
+	for s, v := range map[string]Millis{
+		"1:02.345": 62345,
+		"1:02.3":   62300,
+		"1:02":     62000,
+		"0.05":     50,
+	} {
+		m, err := ParseMillis(s)
+		assertErrNil(t, err)
+		if m != v {
+			t.Fatalf("%s: %d != %d", s, m, v)
+		}
+	}
+	for _, s := range []string{"1.", "1.2345", "1.x", "1:60.5", ".5"} {
+		if _, err := ParseMillis(s); err == nil {
+			t.Fatalf("%s: error expected", s)
+		}
+	}
+}
+
+func TestMarshalMillis(t *testing.T) {
+	b, err := json.Marshal(struct {
+		Pos Millis
+		Neg Millis
+	}{62345, -500})
+	assertErrNil(t, err)
+	assertStrEq(t, `{"Pos":62345,"Neg":-500}`, string(b))
+
+	var v struct {
+		A Millis
+		B Millis
+	}
+	err = json.Unmarshal([]byte(`{"A":"1:02.345","B":"-0.5"}`), &v)
+	assertErrNil(t, err)
+	if v.A != 62345 || v.B != -500 {
+		t.Fatalf("unexpected values: %d, %d", v.A, v.B)
+	}
+	if json.Unmarshal([]byte(`{"A":"foo"}`), &v) == nil {
+		t.Fatal("error expected")
+	}
+
+	for _, m := range []Millis{0, 62345, 3600007, -500} {
+		var v Millis
+		b, err := m.MarshalText()
+		assertErrNil(t, err)
+		assertStrEq(t, m.String(), string(b))
+		assertErrNil(t, v.UnmarshalText(b))
+		if v != m {
+			t.Fatalf("%d != %d", v, m)
+		}
+
+		b, err = json.Marshal(m)
+		assertErrNil(t, err)
+		assertErrNil(t, json.Unmarshal(b, &v))
+		if v != m {
+			t.Fatalf("%d != %d", v, m)
+		}
+	}
+}
+
 func assertStrEq(t *testing.T, a, b string) {
 	if a != b {
 		t.Fatalf("%s != %s", a, b)
@@ -0,0 +1,324 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"sync"
+)
+
+// walkConcurrency is the maximum number of directories listed
+// simultaneously by a single walk.
+const walkConcurrency = 4
+
+// SkipDir and SkipAll are used as return values from WalkFunc
+// the same way as in fs.WalkDir.
+var (
+	SkipDir = fs.SkipDir
+	SkipAll = fs.SkipAll
+)
+
+// Lister is the part of Client required to walk the library.
+type Lister interface {
+	List(path string) ([]Entry, error)
+}
+
+// contextLister is implemented by listers which can abort
+// listing when the walk is canceled.
+type contextLister interface {
+	listContext(ctx context.Context, path string) ([]Entry, error)
+}
+
+// WalkFunc is called by Walk for every visited entry. Semantics of
+// the arguments and of the returned value match fs.WalkDirFunc: if
+// directory listing fails, the function is called for the second
+// time for that directory with the listing error.
+type WalkFunc func(path string, e Entry, err error) error
+
+// Walk walks the library tree rooted at root calling fn for every
+// entry including the root itself. Entries are visited in the order
+// returned by List, but listings of sibling directories are requested
+// ahead in parallel. A directory which has already been visited, e.g.
+// a link to one of its parents, is passed to fn but not descended into.
+func Walk(ctx context.Context, l Lister, root string, fn WalkFunc) error {
+	var list func(ctx context.Context, path string) ([]Entry, error)
+	if cl, ok := l.(contextLister); ok {
+		list = cl.listContext
+	} else {
+		list = func(ctx context.Context, path string) ([]Entry, error) {
+			return l.List(path)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &walker{
+		ctx:     ctx,
+		list:    list,
+		fn:      fn,
+		sem:     make(chan struct{}, walkConcurrency),
+		visited: make(map[string]bool),
+	}
+	err := w.walk(path.Clean(root))
+	cancel()
+	w.wg.Wait()
+
+	return err
+}
+
+// Walk is like the Walk function but aborts pending commands
+// when ctx is canceled.
+func (c *Chubby) Walk(ctx context.Context, root string, fn WalkFunc) error {
+	return Walk(ctx, c, root, fn)
+}
+
+func (c *Chubby) listContext(ctx context.Context, path string) ([]Entry, error) {
+	resp, err := c.Do(ctx, cmdList, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeEntries(resp)
+}
+
+type walker struct {
+	ctx     context.Context
+	list    func(ctx context.Context, path string) ([]Entry, error)
+	fn      WalkFunc
+	sem     chan struct{}
+	wg      sync.WaitGroup
+	visited map[string]bool
+}
+
+// listing is a directory listing requested in background.
+type listing struct {
+	entries []Entry
+	err     error
+	done    chan struct{}
+}
+
+func (w *walker) walk(root string) error {
+	d := &Dir{Path: root, Name: path.Base(root)}
+	err := w.fn(root, d, nil)
+	if err == nil {
+		err = w.walkDir(d, w.prefetch(root))
+	}
+	if err == SkipDir || err == SkipAll {
+		return nil
+	}
+
+	return err
+}
+
+// walkDir calls fn for every entry of the directory and walks its
+// subdirectories. The directory itself has already been passed to fn.
+func (w *walker) walkDir(d *Dir, l *listing) error {
+	w.visited[d.Path] = true
+
+	select {
+	case <-l.done:
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+	if l.err != nil {
+		return w.fn(d.Path, d, l.err)
+	}
+
+	// Listings of subdirectories are requested in a sliding window
+	// walkConcurrency directories ahead of the current entry.
+	var dirs []*Dir
+	for _, e := range l.entries {
+		if e.IsDir() {
+			dirs = append(dirs, e.Dir())
+		}
+	}
+	pending := make(map[*Dir]*listing)
+	next := 0
+	prefetch := func(cur int) {
+		for ; next < len(dirs) && next <= cur+walkConcurrency; next++ {
+			p := path.Clean(dirs[next].Path)
+			if !w.visited[p] {
+				pending[dirs[next]] = w.prefetch(p)
+			}
+		}
+	}
+
+	cur := 0
+	for _, e := range l.entries {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+		if !e.IsDir() {
+			t := e.Track()
+			err := w.fn(path.Clean(t.Path), t, nil)
+			if err == SkipDir {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			continue
+		}
+
+		sub := e.Dir()
+		prefetch(cur)
+		cur++
+		p := path.Clean(sub.Path)
+		err := w.fn(p, sub, nil)
+		if err == SkipDir {
+			continue
+		} else if err != nil {
+			return err
+		}
+		sl, ok := pending[sub]
+		delete(pending, sub)
+		if w.visited[p] || !ok {
+			continue
+		}
+		if p != sub.Path {
+			sub = &Dir{Path: p, Name: sub.Name}
+		}
+		err = w.walkDir(sub, sl)
+		if err == SkipDir {
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prefetch requests the directory listing in background. Number of
+// listings requested simultaneously is limited by the semaphore.
+func (w *walker) prefetch(pth string) *listing {
+	l := &listing{done: make(chan struct{})}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer close(l.done)
+
+		select {
+		case w.sem <- struct{}{}:
+		case <-w.ctx.Done():
+			l.err = w.ctx.Err()
+			return
+		}
+		l.entries, l.err = w.list(w.ctx, pth)
+		<-w.sem
+	}()
+
+	return l
+}
+
+// WalkIter is the streaming form of Walk. Unlike Walk it stops on the
+// first listing error which is reported by Err. It must be closed if
+// Next has not returned false.
+type WalkIter struct {
+	items   chan walkItem
+	replies chan error
+	cancel  context.CancelFunc
+	err     error
+	cur     walkItem
+	reply   error
+	waiting bool
+}
+
+type walkItem struct {
+	path  string
+	entry Entry
+}
+
+// NewWalkIter starts walking the library tree rooted at root.
+func NewWalkIter(ctx context.Context, l Lister, root string) *WalkIter {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &WalkIter{
+		items:   make(chan walkItem),
+		replies: make(chan error, 1),
+		cancel:  cancel,
+	}
+	go func() {
+		err := Walk(ctx, l, root, func(path string, e Entry, err error) error {
+			if err != nil {
+				return err
+			}
+			select {
+			case it.items <- walkItem{path, e}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			select {
+			case err := <-it.replies:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		it.err = err
+		close(it.items)
+	}()
+
+	return it
+}
+
+// Next advances the iterator to the next entry. It returns false when
+// the walk is finished or failed.
+func (it *WalkIter) Next() bool {
+	if it.waiting {
+		it.replies <- it.reply
+		it.waiting = false
+		it.reply = nil
+	}
+	item, ok := <-it.items
+	if !ok {
+		it.cancel()
+		return false
+	}
+	it.cur = item
+	it.waiting = true
+
+	return true
+}
+
+func (it *WalkIter) Path() string {
+	return it.cur.path
+}
+
+func (it *WalkIter) Entry() Entry {
+	return it.cur.entry
+}
+
+// SkipDir makes the iterator skip the current directory if the current
+// entry is a directory, or the rest of the containing directory
+// otherwise.
+func (it *WalkIter) SkipDir() {
+	it.reply = SkipDir
+}
+
+// Err returns the error which stopped the walk, if any. It must be
+// called after Next returned false.
+func (it *WalkIter) Err() error {
+	return it.err
+}
+
+// Close stops the walk and waits for all pending listings to finish.
+func (it *WalkIter) Close() {
+	it.cancel()
+	for range it.items {
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2026 Viacheslav Chimishuk <vchimishuk@yandex.ru>
+//
+// This file is part of chubby.
+//
+// Chub is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Chub is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Chub. If not, see <http://www.gnu.org/licenses/>.
+
+package chubby
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vchimishuk/chubby/textconn"
+)
+
+// synthTree is a synthetic library where every directory contains
+// the given number of tracks and subdirectories interleaved.
+type synthTree struct {
+	dirs  map[string][]string
+	order []string
+}
+
+func newSynthTree(depth, dirs, tracks int) *synthTree {
+	t := &synthTree{dirs: make(map[string][]string)}
+	t.order = append(t.order, "/")
+	t.gen("/", depth, dirs, tracks)
+
+	return t
+}
+
+func (t *synthTree) gen(dir string, depth, dirs, tracks int) {
+	t.dirs[dir] = []string{}
+	for i := 0; i < dirs || i < tracks; i++ {
+		if i < tracks {
+			p := path.Join(dir, fmt.Sprintf("t%d.flac", i))
+			t.order = append(t.order, p)
+			t.dirs[dir] = append(t.dirs[dir], fmt.Sprintf(
+				`type: "track", path: %q, title: "T%d", number: %d, length: 60`,
+				p, i, i+1))
+		}
+		if i < dirs && depth > 0 {
+			p := path.Join(dir, fmt.Sprintf("d%d", i))
+			t.order = append(t.order, p)
+			t.dirs[dir] = append(t.dirs[dir], fmt.Sprintf(
+				`type: "dir", path: %q, name: "d%d"`, p, i))
+			t.gen(p, depth-1, dirs, tracks)
+		}
+	}
+}
+
+// treeResponder answers list commands with the tree directories after
+// the given latency. The maximum number of simultaneously pending
+// list commands is stored to inflight.
+func treeResponder(tree map[string][]string, latency time.Duration,
+	inflight *int32) func(conn *textconn.TextConn) {
+
+	return latencyResponder(latency, func(line string) (string, []string) {
+		name, arg, _ := strings.Cut(line, " ")
+		p, _ := strconv.Unquote(arg)
+		body, ok := tree[p]
+		if name != "list" || !ok {
+			return "ERR directory not found", nil
+		}
+		return "OK", body
+	}, inflight)
+}
+
+func collect(t *testing.T, c *Chubby, root string,
+	fn func(path string, e Entry) error) []string {
+
+	var paths []string
+	err := c.Walk(context.Background(), root,
+		func(path string, e Entry, err error) error {
+			if err != nil {
+				return err
+			}
+			paths = append(paths, path)
+			if fn != nil {
+				return fn(path, e)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return paths
+}
+
+func TestWalk(t *testing.T) {
+	checkLeaks(t)
+	tree := newSynthTree(6, 3, 2)
+	var inflight int32
+	srv := newTestServer(t, treeResponder(tree.dirs, time.Millisecond, &inflight))
+	c := srv.connect(t)
+
+	paths := collect(t, c, "/", nil)
+	if len(paths) != len(tree.order) {
+		t.Fatalf("%d entries walked, %d expected", len(paths), len(tree.order))
+	}
+	for i, p := range paths {
+		if p != tree.order[i] {
+			t.Fatalf("%d: %s != %s", i, p, tree.order[i])
+		}
+	}
+	n := atomic.LoadInt32(&inflight)
+	if n < 2 || n > walkConcurrency {
+		t.Fatalf("unexpected number of concurrent listings: %d", n)
+	}
+}
+
+func TestWalkSkip(t *testing.T) {
+	checkLeaks(t)
+	tree := newSynthTree(3, 2, 2)
+	var inflight int32
+	srv := newTestServer(t, treeResponder(tree.dirs, 0, &inflight))
+	c := srv.connect(t)
+
+	paths := collect(t, c, "/d0", func(path string, e Entry) error {
+		if path == "/d0/d1" || path == "/d0/d0/t0.flac" {
+			return SkipDir
+		}
+		return nil
+	})
+	exp := []string{"/d0", "/d0/t0.flac", "/d0/d0", "/d0/d0/t0.flac",
+		"/d0/t1.flac", "/d0/d1"}
+	if strings.Join(paths, " ") != strings.Join(exp, " ") {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+
+	paths = collect(t, c, "/", func(path string, e Entry) error {
+		if path == "/d0/d0" {
+			return SkipAll
+		}
+		return nil
+	})
+	if paths[len(paths)-1] != "/d0/d0" {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+
+	paths = collect(t, c, "/", func(path string, e Entry) error {
+		return SkipDir
+	})
+	if len(paths) != 1 || paths[0] != "/" {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, treeResponder(map[string][]string{
+		"/": {`type: "dir", path: "/a", name: "a"`},
+		"/a": {`type: "dir", path: "/a/b/", name: "b"`,
+			`type: "dir", path: "/", name: "up"`},
+		"/a/b": {`type: "dir", path: "/a", name: "loop"`,
+			`type: "track", path: "/a/b/t.flac", title: "T"`},
+	}, 0, new(int32)))
+	c := srv.connect(t)
+
+	paths := collect(t, c, "/", nil)
+	exp := []string{"/", "/a", "/a/b", "/a", "/a/b/t.flac", "/"}
+	if strings.Join(paths, " ") != strings.Join(exp, " ") {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	checkLeaks(t)
+	srv := newTestServer(t, treeResponder(map[string][]string{
+		"/": {`type: "dir", path: "/missing", name: "missing"`,
+			`type: "track", path: "/t.flac", title: "T"`},
+	}, 0, new(int32)))
+	c := srv.connect(t)
+
+	var errs []string
+	var paths []string
+	err := c.Walk(context.Background(), "/",
+		func(path string, e Entry, err error) error {
+			if err != nil {
+				errs = append(errs, path)
+				if !IsServerError(err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return nil
+			}
+			paths = append(paths, path)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(errs, " ") != "/missing" ||
+		strings.Join(paths, " ") != "/ /missing /t.flac" {
+		t.Fatalf("unexpected walk: %v, %v", paths, errs)
+	}
+
+	stop := errors.New("stop")
+	err = c.Walk(context.Background(), "/missing",
+		func(path string, e Entry, err error) error {
+			if err != nil {
+				return stop
+			}
+			return nil
+		})
+	if err != stop {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWalkCanceled(t *testing.T) {
+	checkLeaks(t)
+	tree := newSynthTree(4, 3, 1)
+	srv := newTestServer(t, treeResponder(tree.dirs, time.Millisecond, new(int32)))
+	c := srv.connect(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	err := c.Walk(ctx, "/", func(path string, e Entry, err error) error {
+		n++
+		if n == 10 {
+			cancel()
+		}
+		return err
+	})
+	if err != context.Canceled || n != 10 {
+		t.Fatalf("unexpected result: %d, %v", n, err)
+	}
+	if !c.Connected() {
+		t.Fatal("not connected")
+	}
+}
+
+func TestWalkIter(t *testing.T) {
+	checkLeaks(t)
+	tree := newSynthTree(4, 2, 2)
+	srv := newTestServer(t, treeResponder(tree.dirs, 0, new(int32)))
+	c := srv.connect(t)
+
+	it := NewWalkIter(context.Background(), c, "/")
+	var paths []string
+	for it.Next() {
+		paths = append(paths, it.Path())
+		if it.Entry().IsDir() != !strings.HasSuffix(it.Path(), ".flac") {
+			t.Fatalf("unexpected entry: %s", it.Path())
+		}
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if strings.Join(paths, " ") != strings.Join(tree.order, " ") {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+
+	it = NewWalkIter(context.Background(), c, "/")
+	paths = nil
+	for it.Next() {
+		paths = append(paths, it.Path())
+		if it.Entry().IsDir() && it.Path() != "/" {
+			it.SkipDir()
+		}
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	exp := "/ /t0.flac /d0 /t1.flac /d1"
+	if strings.Join(paths, " ") != exp {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+
+	it = NewWalkIter(context.Background(), c, "/")
+	if !it.Next() || !it.Next() {
+		t.Fatal("entries expected")
+	}
+	it.Close()
+
+	it = NewWalkIter(context.Background(), c, "/missing")
+	if !it.Next() || it.Next() || !IsServerError(it.Err()) {
+		t.Fatalf("unexpected error: %v", it.Err())
+	}
+}